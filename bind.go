@@ -0,0 +1,557 @@
+package nice
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bind struct tags
+const (
+	tagParam    = "param"
+	tagQuery    = "query"
+	tagForm     = "form"
+	tagCookie   = "cookie"
+	tagValidate = "validate"
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// FieldError describes a field which failed to bind or validate
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Rule    string `json:"rule" xml:"rule"`
+	Param   string `json:"param,omitempty" xml:"param,omitempty"`
+	Message string `json:"message" xml:"message"`
+}
+
+// Error implements error interface
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is a list of field errors returned by Bind and Validate
+type ValidationErrors []FieldError
+
+// Error implements error interface
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i := range e {
+		s[i] = e[i].Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Bind decodes request body by Content-Type into dst, then fills fields from
+// route params, query, form, multipart and cookies by struct tags,
+// finally validates dst by `validate` tags.
+//
+// Example:
+//	type User struct {
+//		ID    int                   `param:"id"`
+//		Page  *int                  `query:"page" validate:"min=1"`
+//		Name  string                `json:"name" form:"name" validate:"required,max=32"`
+//		Role  string                `form:"role" validate:"omitempty,enum=admin|user"`
+//		Email string                `json:"email" validate:"omitempty,regex=^\\S+@\\S+$"`
+//		Sid   string                `cookie:"sid"`
+//		Photo *multipart.FileHeader `form:"photo"`
+//	}
+//
+// body values are overwritten by form, query, cookie then route params.
+func (c *Context) Bind(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("nice.Bind dst must be a pointer to struct")
+	}
+
+	if err := c.bindBody(dst); err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	c.bindStruct(rv.Elem(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return Validate(dst)
+}
+
+// bindBody decodes request body by Content-Type
+func (c *Context) bindBody(dst interface{}) error {
+	if c.Req.Body == nil {
+		return nil
+	}
+	ct, _, _ := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	switch {
+	case ct == ApplicationJSON || strings.HasSuffix(ct, "+json"):
+		err := c.QueryJSON(dst)
+		if err == ErrJSONPayloadEmpty {
+			return nil
+		}
+		return err
+	case ct == ApplicationXML || ct == "text/xml" || strings.HasSuffix(ct, "+xml"):
+		err := c.QueryXML(dst)
+		if err == ErrXMLPayloadEmpty {
+			return nil
+		}
+		return err
+	case ct == ApplicationForm || ct == MultipartForm:
+		return c.ParseForm(0)
+	}
+	return nil
+}
+
+// bindStruct fills struct fields by bind tags
+func (c *Context) bindStruct(v reflect.Value, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			c.bindStruct(fv, errs)
+			continue
+		}
+
+		for _, tag := range []string{tagForm, tagQuery, tagCookie, tagParam} {
+			name := sf.Tag.Get(tag)
+			if name == "" || name == "-" {
+				continue
+			}
+			if tag == tagForm && (sf.Type == fileHeaderType || sf.Type == fileHeaderSliceType) {
+				c.bindFile(name, fv)
+				continue
+			}
+			vals := c.bindValues(tag, name)
+			if len(vals) == 0 {
+				continue
+			}
+			if err := setValues(fv, vals); err != nil {
+				*errs = append(*errs, FieldError{
+					Field:   name,
+					Rule:    "type",
+					Param:   sf.Type.String(),
+					Message: fmt.Sprintf("cannot bind %q to %s", vals[0], sf.Type),
+				})
+			}
+		}
+	}
+}
+
+// bindValues returns values from the given source
+func (c *Context) bindValues(source, name string) []string {
+	switch source {
+	case tagParam:
+		for i := len(c.pNames) - 1; i >= 0; i-- {
+			if c.pNames[i] == name {
+				return []string{c.pValues[i]}
+			}
+		}
+	case tagQuery:
+		if c.Req.URL != nil {
+			return c.Req.URL.Query()[name]
+		}
+	case tagForm:
+		if c.ParseForm(0) != nil {
+			return nil
+		}
+		if v, ok := c.Req.PostForm[name]; ok {
+			return v
+		}
+		if c.Req.MultipartForm != nil {
+			return c.Req.MultipartForm.Value[name]
+		}
+	case tagCookie:
+		if _, err := c.Req.Cookie(name); err == nil {
+			return []string{c.GetCookie(name)}
+		}
+	}
+	return nil
+}
+
+// bindFile sets multipart file headers to field
+func (c *Context) bindFile(name string, fv reflect.Value) {
+	if c.ParseForm(0) != nil || c.Req.MultipartForm == nil {
+		return
+	}
+	files := c.Req.MultipartForm.File[name]
+	if len(files) == 0 {
+		return
+	}
+	if fv.Type() == fileHeaderType {
+		fv.Set(reflect.ValueOf(files[0]))
+		return
+	}
+	fv.Set(reflect.ValueOf(files))
+}
+
+// setValues converts string values to field type
+func setValues(fv reflect.Value, vals []string) error {
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValues(fv.Elem(), vals)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			fv.SetBytes([]byte(vals[0]))
+			return nil
+		}
+		s := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i := range vals {
+			if err := setValue(s.Index(i), vals[i]); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	return setValue(fv, vals[0])
+}
+
+// setValue converts string value to field type
+func setValue(fv reflect.Value, val string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(v)
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValue(fv.Elem(), val)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// Validate checks struct fields by `validate` tag, nested structs are validated too.
+// rules are separated by comma, supported rules:
+//	required       value must not be zero value
+//	omitempty      skips other rules if value is zero value
+//	min=n, max=n   number range, or length of string, slice and map
+//	enum=a|b|c     value must be one of the list
+//	regex=pattern  string must match the pattern, must be the last rule
+// rules except required are skipped when the field is absent, that is nil pointer,
+// slice, map or interface. rules are parsed once when the type is first validated,
+// an invalid rule returns an error which is not ValidationErrors.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateRule is a parsed validate rule
+type validateRule struct {
+	rule  string
+	param string
+	limit float64        // of min and max
+	re    *regexp.Regexp // of regex
+}
+
+// fieldRules is a struct field to validate
+type fieldRules struct {
+	index     int
+	name      string
+	anonymous bool
+	nested    bool // struct or pointer to struct, validated recursively
+	rules     []validateRule
+}
+
+// structRules is the parsed validate rules of a struct type
+type structRules struct {
+	fields []fieldRules
+	err    error
+}
+
+var (
+	rulesCache      = make(map[reflect.Type]*structRules)
+	rulesCacheMutex sync.RWMutex
+)
+
+// typeRules returns the validate rules of struct type, they are parsed once
+func typeRules(t reflect.Type) *structRules {
+	rulesCacheMutex.RLock()
+	sr := rulesCache[t]
+	rulesCacheMutex.RUnlock()
+	if sr != nil {
+		return sr
+	}
+	sr = parseStructRules(t)
+	rulesCacheMutex.Lock()
+	rulesCache[t] = sr
+	rulesCacheMutex.Unlock()
+	return sr
+}
+
+// parseStructRules parses and checks `validate` tags of struct fields
+func parseStructRules(t reflect.Type) *structRules {
+	sr := new(structRules)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		f := fieldRules{index: i, name: fieldName(sf), anonymous: sf.Anonymous}
+		if tag := sf.Tag.Get(tagValidate); tag != "" && tag != "-" {
+			for _, pair := range parseRules(tag) {
+				r, err := newValidateRule(pair[0], pair[1])
+				if err != nil {
+					sr.err = fmt.Errorf("nice: invalid validate tag of %s.%s: %v", t, sf.Name, err)
+					return sr
+				}
+				f.rules = append(f.rules, r)
+			}
+		}
+		st := sf.Type
+		for st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		f.nested = st.Kind() == reflect.Struct && st != fileHeaderType.Elem() && st.NumField() > 0
+		if len(f.rules) > 0 || f.nested {
+			sr.fields = append(sr.fields, f)
+		}
+	}
+	return sr
+}
+
+// newValidateRule checks the rule and parses its param
+func newValidateRule(rule, param string) (validateRule, error) {
+	r := validateRule{rule: rule, param: param}
+	var err error
+	switch rule {
+	case "required", "omitempty":
+	case "min", "max":
+		if r.limit, err = strconv.ParseFloat(param, 64); err != nil {
+			return r, fmt.Errorf("%s rule param [%s] is not a number", rule, param)
+		}
+	case "enum":
+		if param == "" {
+			return r, fmt.Errorf("enum rule has no value")
+		}
+	case "regex":
+		if r.re, err = regexp.Compile(param); err != nil {
+			return r, fmt.Errorf("regex rule param [%s] is invalid: %v", param, err)
+		}
+	default:
+		return r, fmt.Errorf("unknown rule [%s]", rule)
+	}
+	return r, nil
+}
+
+// validateStruct checks struct fields by `validate` tag
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) error {
+	sr := typeRules(v.Type())
+	if sr.err != nil {
+		return sr.err
+	}
+	for _, f := range sr.fields {
+		fv := v.Field(f.index)
+		name := prefix + f.name
+		validateField(fv, name, f.rules, errs)
+
+		if !f.nested {
+			continue
+		}
+		sv := fv
+		for sv.Kind() == reflect.Ptr && !sv.IsNil() {
+			sv = sv.Elem()
+		}
+		if sv.Kind() != reflect.Struct {
+			continue
+		}
+		p := name + "."
+		if f.anonymous {
+			p = prefix
+		}
+		if err := validateStruct(sv, p, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRules splits validate tag into rule and param pairs,
+// regex must be the last rule because it could contain comma.
+func parseRules(rules string) [][2]string {
+	var pairs [][2]string
+	for len(rules) > 0 {
+		var rule string
+		if strings.HasPrefix(rules, "regex=") {
+			rule, rules = rules, ""
+		} else if i := strings.IndexByte(rules, ','); i >= 0 {
+			rule, rules = rules[:i], rules[i+1:]
+		} else {
+			rule, rules = rules, ""
+		}
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		param := ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			rule, param = rule[:i], rule[i+1:]
+		}
+		pairs = append(pairs, [2]string{rule, param})
+	}
+	return pairs
+}
+
+// validateField checks a field by rules
+func validateField(fv reflect.Value, name string, rules []validateRule, errs *ValidationErrors) {
+	for _, r := range rules {
+		switch r.rule {
+		case "required":
+			if isZero(fv) {
+				*errs = append(*errs, FieldError{Field: name, Rule: r.rule, Message: "is required"})
+				return
+			}
+			continue
+		case "omitempty":
+			if isZero(fv) {
+				return
+			}
+			continue
+		}
+
+		// other rules ignore absent value, use required to check it
+		ev := fv
+		for ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				return
+			}
+			ev = ev.Elem()
+		}
+		switch ev.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface:
+			if ev.IsNil() {
+				return
+			}
+		}
+		if msg := r.check(ev); msg != "" {
+			*errs = append(*errs, FieldError{Field: name, Rule: r.rule, Param: r.param, Message: msg})
+		}
+	}
+}
+
+// check returns error message if value breaks the rule
+func (r validateRule) check(v reflect.Value) string {
+	switch r.rule {
+	case "min", "max":
+		var n float64
+		var unit string
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		case reflect.String:
+			n = float64(len([]rune(v.String())))
+			unit = " characters"
+		case reflect.Slice, reflect.Map, reflect.Array:
+			n = float64(v.Len())
+			unit = " items"
+		default:
+			return ""
+		}
+		if r.rule == "min" && n < r.limit {
+			return "must be at least " + r.param + unit
+		}
+		if r.rule == "max" && n > r.limit {
+			return "must be at most " + r.param + unit
+		}
+	case "enum":
+		s := fmt.Sprint(v.Interface())
+		for _, e := range strings.Split(r.param, "|") {
+			if s == e {
+				return ""
+			}
+		}
+		return "must be one of [" + strings.Replace(r.param, "|", ", ", -1) + "]"
+	case "regex":
+		if v.Kind() != reflect.String {
+			return ""
+		}
+		if !r.re.MatchString(v.String()) {
+			return "must match " + r.param
+		}
+	}
+	return ""
+}
+
+// fieldName returns the name used in request of the struct field
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "xml", tagForm, tagQuery, tagParam, tagCookie} {
+		name := sf.Tag.Get(tag)
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// isZero reports whether v is the zero value
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
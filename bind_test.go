@@ -0,0 +1,174 @@
+package nice
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type bindAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type bindUser struct {
+	ID      int64                 `param:"id"`
+	Page    *int                  `query:"page" validate:"min=1,max=100"`
+	Tags    []string              `query:"tag" validate:"max=2"`
+	Name    string                `json:"name" xml:"name" form:"name" validate:"required,max=8"`
+	Role    string                `json:"role" xml:"role" form:"role" validate:"omitempty,enum=admin|user"`
+	Email   string                `json:"email" xml:"email" validate:"omitempty,regex=^[a-z]+@[a-z.]+$"`
+	Level   int                   `query:"level" validate:"max=9"`
+	Sid     string                `cookie:"sid"`
+	Age     *int                  `form:"age"`
+	Address *bindAddress          `json:"address"`
+	Photo   *multipart.FileHeader `form:"photo"`
+}
+
+func TestBind1(t *testing.T) {
+	Convey("bind request", t, func() {
+		b2 := New()
+		var u bindUser
+		var err error
+		b2.Route("/bind/:id", "GET,POST", func(c *Context) {
+			u = bindUser{}
+			err = c.Bind(&u)
+		})
+
+		Convey("bind json body, param, query and cookie", func() {
+			body := `{"name":"nice","role":"admin","email":"a@b.com","address":{"city":"sz"}}`
+			req, _ := http.NewRequest("POST", "/bind/12?page=2&tag=a&tag=b", strings.NewReader(body))
+			req.Header.Set("Content-Type", ApplicationJSONCharsetUTF8)
+			req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldBeNil)
+			So(u.ID, ShouldEqual, 12)
+			So(*u.Page, ShouldEqual, 2)
+			So(u.Tags, ShouldResemble, []string{"a", "b"})
+			So(u.Name, ShouldEqual, "nice")
+			So(u.Sid, ShouldEqual, "s1")
+			So(u.Address.City, ShouldEqual, "sz")
+		})
+
+		Convey("bind xml body", func() {
+			body := `<user><name>nice</name><role>user</role></user>`
+			req, _ := http.NewRequest("POST", "/bind/1?page=1", strings.NewReader(body))
+			req.Header.Set("Content-Type", ApplicationXML)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldBeNil)
+			So(u.Name, ShouldEqual, "nice")
+			So(u.Role, ShouldEqual, "user")
+		})
+
+		Convey("bind form", func() {
+			req, _ := http.NewRequest("POST", "/bind/1", strings.NewReader("name=nice&age=18"))
+			req.Header.Set("Content-Type", ApplicationForm)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldBeNil)
+			So(u.Name, ShouldEqual, "nice")
+			So(*u.Age, ShouldEqual, 18)
+		})
+
+		Convey("bind multipart", func() {
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			mw.WriteField("name", "nice")
+			fw, _ := mw.CreateFormFile("photo", "nice.jpg")
+			fw.Write([]byte("jpg"))
+			mw.Close()
+			req, _ := http.NewRequest("POST", "/bind/1", body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldBeNil)
+			So(u.Name, ShouldEqual, "nice")
+			So(u.Photo, ShouldNotBeNil)
+			So(u.Photo.Filename, ShouldEqual, "nice.jpg")
+		})
+
+		Convey("bind type error", func() {
+			req, _ := http.NewRequest("GET", "/bind/abc?name=nice", nil)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldHaveSameTypeAs, ValidationErrors{})
+			errs := err.(ValidationErrors)
+			So(errs[0].Field, ShouldEqual, "id")
+			So(errs[0].Rule, ShouldEqual, "type")
+		})
+
+		Convey("validate error", func() {
+			body := `{"name":"nice-framework","role":"root","email":"A@B","address":{}}`
+			req, _ := http.NewRequest("POST", "/bind/1?page=0&tag=a&tag=b&tag=c", strings.NewReader(body))
+			req.Header.Set("Content-Type", ApplicationJSON)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldNotBeNil)
+			rules := make(map[string]string)
+			for _, e := range err.(ValidationErrors) {
+				rules[e.Field] = e.Rule
+			}
+			So(rules, ShouldResemble, map[string]string{
+				"page":         "min",
+				"tag":          "max",
+				"name":         "max",
+				"role":         "enum",
+				"email":        "regex",
+				"address.city": "required",
+			})
+		})
+
+		Convey("bind invalid json", func() {
+			req, _ := http.NewRequest("POST", "/bind/1", strings.NewReader("{"))
+			req.Header.Set("Content-Type", ApplicationJSON)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(err, ShouldNotBeNil)
+			_, ok := err.(ValidationErrors)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestValidate1(t *testing.T) {
+	Convey("validate struct", t, func() {
+		So(Validate(nil), ShouldBeNil)
+		So(Validate(&bindUser{Name: "nice"}), ShouldBeNil)
+		err := Validate(bindUser{})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "name: is required")
+
+		err = Validate(&bindUser{Name: "nice", Level: 10, Tags: []string{}})
+		So(err, ShouldHaveSameTypeAs, ValidationErrors{})
+		So(err.Error(), ShouldEqual, "level: must be at most 9")
+	})
+}
+
+type bindBadMin struct {
+	Page int `validate:"min=one"`
+}
+
+type bindBadRule struct {
+	Name string `validate:"required,size=8"`
+}
+
+type bindBadNested struct {
+	Rule *bindBadRule `json:"rule"`
+}
+
+func TestValidate2(t *testing.T) {
+	Convey("validate invalid tags", t, func() {
+		err := Validate(bindBadMin{Page: 1})
+		So(err, ShouldNotBeNil)
+		_, ok := err.(ValidationErrors)
+		So(ok, ShouldBeFalse)
+		So(err.Error(), ShouldContainSubstring, "min rule param [one] is not a number")
+
+		err = Validate(&bindBadRule{})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "unknown rule [size]")
+		So(Validate(&bindBadRule{}), ShouldEqual, err)
+
+		So(Validate(bindBadNested{}), ShouldBeNil)
+		So(Validate(bindBadNested{Rule: &bindBadRule{}}), ShouldEqual, err)
+	})
+}
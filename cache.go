@@ -1,7 +1,31 @@
 package nice
 
+import (
+	"context"
+	"time"
+)
+
 type Cache interface {
 	Open()
 	Close() error
 	Do(command string, args ...interface{}) (interface{}, error)
 }
+
+// CacheContext is a Cache which honors context deadline and cancellation
+type CacheContext interface {
+	Cache
+	DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error)
+}
+
+// CacheStats is a Cache reports stats of connection pool
+type CacheStats interface {
+	Stats() PoolStats
+}
+
+// PoolStats is stats of connection pool
+type PoolStats struct {
+	ActiveCount  int           // connections in the pool, idle and in use
+	IdleCount    int           // idle connections
+	WaitCount    int64         // total number of connections waited for
+	WaitDuration time.Duration // total time waited for connections
+}
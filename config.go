@@ -0,0 +1,591 @@
+package nice
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigDecoder decodes content of a config file
+type ConfigDecoder func(data []byte) (map[string]interface{}, error)
+
+// configDecoders storage decoders by file extension
+var configDecoders = map[string]ConfigDecoder{
+	".yaml": decodeYAML,
+	".yml":  decodeYAML,
+	".json": decodeJSON,
+	".toml": decodeTOML,
+}
+
+// RegisterConfigDecoder registers a decoder for config files with the extension, like .ini
+func RegisterConfigDecoder(ext string, d ConfigDecoder) {
+	configDecoders[strings.ToLower(ext)] = d
+}
+
+// ConfigSource is a source of configuration, like file, env and flags
+type ConfigSource interface {
+	Load() (map[string]interface{}, error)
+}
+
+// ConfigSourceFunc is an adapter to use func as ConfigSource
+type ConfigSourceFunc func() (map[string]interface{}, error)
+
+// Load calls f()
+func (f ConfigSourceFunc) Load() (map[string]interface{}, error) {
+	return f()
+}
+
+// Config is layered configuration, values of the later source override the former.
+// keys are case insensitive, nested keys are joined by dot, like mysql.master.host
+type Config struct {
+	sources    []ConfigSource
+	data       map[string]interface{}
+	validators []func(*Config) error
+	handlers   []configHandler
+	onError    func(error)
+	stop       chan struct{}
+	mutex      sync.RWMutex
+	loadMutex  sync.Mutex
+}
+
+// configHandler is handler of changes of the key
+type configHandler struct {
+	key string
+	f   func(old, new interface{})
+}
+
+// NewConfig create a config with sources, call Load to read them
+func NewConfig(sources ...ConfigSource) *Config {
+	return &Config{
+		sources: sources,
+		data:    make(map[string]interface{}),
+	}
+}
+
+// Add appends sources which override the existing sources
+func (c *Config) Add(sources ...ConfigSource) *Config {
+	c.mutex.Lock()
+	c.sources = append(c.sources, sources...)
+	c.mutex.Unlock()
+	return c
+}
+
+// Validate registers validators, they are called with the new config in
+// Load, the config is not changed if any of them returns error
+func (c *Config) Validate(f ...func(*Config) error) *Config {
+	c.mutex.Lock()
+	c.validators = append(c.validators, f...)
+	c.mutex.Unlock()
+	return c
+}
+
+// OnChange registers handler called after Load when value of the key is changed,
+// the key is empty for any change, old is nil when the key is added, new is nil when removed.
+func (c *Config) OnChange(key string, f func(old, new interface{})) *Config {
+	c.mutex.Lock()
+	c.handlers = append(c.handlers, configHandler{key: key, f: f})
+	c.mutex.Unlock()
+	return c
+}
+
+// OnError registers handler of errors when reloading in Watch, and converting
+// in String, Int, Bool and Float. errors are printed by the standard logger if not set.
+func (c *Config) OnError(f func(error)) *Config {
+	c.mutex.Lock()
+	c.onError = f
+	c.mutex.Unlock()
+	return c
+}
+
+// reportError calls the error handler, or prints err if not set
+func (c *Config) reportError(err error) {
+	c.mutex.RLock()
+	onError := c.onError
+	c.mutex.RUnlock()
+	if onError != nil {
+		onError(err)
+		return
+	}
+	log.Println(err)
+}
+
+// Load reads all sources and merges them in order, then validates
+// the new config and notifies handlers of changed keys
+func (c *Config) Load() error {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+
+	c.mutex.RLock()
+	sources, validators := c.sources, c.validators
+	c.mutex.RUnlock()
+
+	data := make(map[string]interface{})
+	for _, s := range sources {
+		m, err := s.Load()
+		if err != nil {
+			return err
+		}
+		mergeConfig(data, m)
+	}
+
+	next := &Config{data: data}
+	for _, v := range validators {
+		if err := v(next); err != nil {
+			return fmt.Errorf("config: validate failed: %v", err)
+		}
+	}
+
+	c.mutex.Lock()
+	prev := &Config{data: c.data}
+	c.data = data
+	handlers := c.handlers
+	c.mutex.Unlock()
+
+	for _, h := range handlers {
+		old, new := prev.Get(h.key), next.Get(h.key)
+		if !reflect.DeepEqual(old, new) {
+			h.f(old, new)
+		}
+	}
+	return nil
+}
+
+// Watch reloads config every interval when files of sources are modified,
+// errors are passed to the handler registered by OnError, the config is not
+// changed if reloading failed. call StopWatch to stop.
+func (c *Config) Watch(interval time.Duration) {
+	c.mutex.Lock()
+	if c.stop != nil {
+		c.mutex.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	c.stop = stop
+	c.mutex.Unlock()
+
+	stats := c.stats()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			current := c.stats()
+			if reflect.DeepEqual(stats, current) {
+				continue
+			}
+			stats = current
+			if err := c.Load(); err != nil {
+				c.reportError(err)
+			}
+		}
+	}()
+}
+
+// StopWatch stops watching files
+func (c *Config) StopWatch() {
+	c.mutex.Lock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.mutex.Unlock()
+}
+
+// fileStat is modified time and size of watched file
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// stats returns stats of files of sources, missing files are zero value
+func (c *Config) stats() map[string]fileStat {
+	c.mutex.RLock()
+	sources := c.sources
+	c.mutex.RUnlock()
+
+	stats := make(map[string]fileStat)
+	for _, s := range sources {
+		fs, ok := s.(interface {
+			Files() []string
+		})
+		if !ok {
+			continue
+		}
+		for _, file := range fs.Files() {
+			var stat fileStat
+			if fi, err := os.Stat(file); err == nil {
+				stat = fileStat{modTime: fi.ModTime(), size: fi.Size()}
+			}
+			stats[file] = stat
+		}
+	}
+	return stats
+}
+
+// Map returns all values, nested values are map[string]interface{}
+func (c *Config) Map() map[string]interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.data
+}
+
+// Get returns value of the key, nil if not set
+func (c *Config) Get(key string) interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if key == "" {
+		return c.data
+	}
+	var v interface{} = c.data
+	for _, k := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if v, ok = m[k]; !ok {
+			return nil
+		}
+	}
+	return v
+}
+
+// Has returns whether the key is set
+func (c *Config) Has(key string) bool {
+	return c.Get(key) != nil
+}
+
+// String returns value of the key as string, zero value if not set or it can not
+// be converted, the error is reported to the OnError handler
+func (c *Config) String(key string) string {
+	var v string
+	c.decodeValue(key, &v)
+	return v
+}
+
+// Int returns value of the key as int, see String
+func (c *Config) Int(key string) int {
+	var v int
+	c.decodeValue(key, &v)
+	return v
+}
+
+// Bool returns value of the key as bool, see String
+func (c *Config) Bool(key string) bool {
+	var v bool
+	c.decodeValue(key, &v)
+	return v
+}
+
+// Float returns value of the key as float64, see String
+func (c *Config) Float(key string) float64 {
+	var v float64
+	c.decodeValue(key, &v)
+	return v
+}
+
+// decodeValue decodes value of the key into v, the error is reported by reportError
+func (c *Config) decodeValue(key string, v interface{}) {
+	if err := c.Decode(key, v); err != nil {
+		c.reportError(err)
+	}
+}
+
+// Decode decodes value of the key into v, the whole config if key is empty.
+// fields of struct are matched by yaml tag or name case insensitively,
+// fields not set use the value of default tag, like:
+//
+//	type RedisConf struct {
+//		Host    string        `yaml:"host" default:"127.0.0.1:6379"`
+//		Timeout time.Duration `yaml:"timeout" default:"3s"`
+//	}
+func (c *Config) Decode(key string, v interface{}) error {
+	if err := setDefaults(reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	value := c.Get(key)
+	if value == nil {
+		return nil
+	}
+	if err := decodeConfig(value, v); err != nil {
+		return fmt.Errorf("config: decode %s failed: %v", key, err)
+	}
+	return nil
+}
+
+// decodeConf decodes config of service into v, config could be the struct,
+// pointer of the struct, map or *Config, fields not set use default tag
+func decodeConf(config interface{}, v interface{}) error {
+	if config == nil {
+		return errors.New("config: config is nil")
+	}
+	if c, ok := config.(*Config); ok {
+		return c.Decode("", v)
+	}
+	rv, cv := reflect.ValueOf(v).Elem(), reflect.ValueOf(config)
+	if cv.Kind() == reflect.Ptr && cv.Type().Elem() == rv.Type() {
+		cv = cv.Elem()
+	}
+	if cv.Type() == rv.Type() {
+		rv.Set(cv)
+		return setDefaults(rv.Addr())
+	}
+	if err := setDefaults(rv.Addr()); err != nil {
+		return err
+	}
+	return decodeConfig(normalizeConfig(config), v)
+}
+
+// decodeConfig decodes config value into v with weak type conversion
+func decodeConfig(value, v interface{}) error {
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		TagName:          "yaml",
+		Result:           v,
+	})
+	if err != nil {
+		return err
+	}
+	return d.Decode(value)
+}
+
+// setDefaults sets fields with the value of default tag
+func setDefaults(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if def, ok := t.Field(i).Tag.Lookup("default"); ok && field.IsZero() {
+			if err := decodeConfig(def, field.Addr().Interface()); err != nil {
+				return fmt.Errorf("config: default of %s is invalid: %v", t.Field(i).Name, err)
+			}
+			continue
+		}
+		if err := setDefaults(field.Addr()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeConfig merges src into dst deeply
+func mergeConfig(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if sm, ok := v.(map[string]interface{}); ok {
+			if dm, ok := dst[k].(map[string]interface{}); ok {
+				mergeConfig(dm, sm)
+				continue
+			}
+			dm := make(map[string]interface{})
+			mergeConfig(dm, sm)
+			v = dm
+		}
+		dst[k] = v
+	}
+}
+
+// setConfig sets value by the path of keys, maps are created if needed
+func setConfig(m map[string]interface{}, keys []string, v interface{}) {
+	for _, k := range keys[:len(keys)-1] {
+		sub, ok := m[k].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			m[k] = sub
+		}
+		m = sub
+	}
+	m[keys[len(keys)-1]] = v
+}
+
+// normalizeConfig converts maps to map[string]interface{} with lower case keys
+func normalizeConfig(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[strings.ToLower(fmt.Sprint(k))] = normalizeConfig(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[strings.ToLower(k)] = normalizeConfig(value)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeConfig(v[i])
+		}
+	case []map[string]interface{}:
+		s := make([]interface{}, len(v))
+		for i := range v {
+			s[i] = normalizeConfig(v[i])
+		}
+		return s
+	}
+	return v
+}
+
+// decodeYAML decodes yaml content
+func decodeYAML(data []byte) (map[string]interface{}, error) {
+	m := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeConfig(m).(map[string]interface{}), nil
+}
+
+// decodeJSON decodes json content
+func decodeJSON(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeConfig(m).(map[string]interface{}), nil
+}
+
+// decodeTOML decodes toml content
+func decodeTOML(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		return nil, err
+	}
+	return normalizeConfig(m).(map[string]interface{}), nil
+}
+
+// fileSource is config source of file
+type fileSource struct {
+	path     string
+	optional bool
+}
+
+// ConfigFile returns a source reads file, format is detected by extension,
+// .yaml .yml .json .toml are supported. the overlay file of the runtime
+// environment, like app.production.yaml for app.yaml, is merged if it exists.
+func ConfigFile(path string) ConfigSource {
+	return &fileSource{path: path}
+}
+
+// ConfigFileOptional returns a source like ConfigFile, but the file may not exist
+func ConfigFileOptional(path string) ConfigSource {
+	return &fileSource{path: path, optional: true}
+}
+
+// Files returns the file and the overlay file of the runtime environment
+func (s *fileSource) Files() []string {
+	ext := filepath.Ext(s.path)
+	return []string{s.path, strings.TrimSuffix(s.path, ext) + "." + Env + ext}
+}
+
+// Load reads the file and the overlay file
+func (s *fileSource) Load() (map[string]interface{}, error) {
+	files := s.Files()
+	m, err := readConfigFile(files[0])
+	if err != nil {
+		if !s.optional || !os.IsNotExist(err) {
+			return nil, err
+		}
+		m = make(map[string]interface{})
+	}
+	overlay, err := readConfigFile(files[1])
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	mergeConfig(m, overlay)
+	return m, nil
+}
+
+// readConfigFile reads and decodes the file
+func readConfigFile(path string) (map[string]interface{}, error) {
+	decoder := configDecoders[strings.ToLower(filepath.Ext(path))]
+	if decoder == nil {
+		return nil, errors.New("config: unsupported file format " + path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decoder(data)
+	if err != nil {
+		return nil, fmt.Errorf("config: parse %s failed: %v", path, err)
+	}
+	return m, nil
+}
+
+// ConfigEnv returns a source reads environment variables with the prefix,
+// double underscore separates nested keys, like APP_MYSQL__MASTER__HOST
+// for mysql.master.host with prefix APP
+func ConfigEnv(prefix string) ConfigSource {
+	prefix = strings.ToUpper(prefix) + "_"
+	return ConfigSourceFunc(func() (map[string]interface{}, error) {
+		m := make(map[string]interface{})
+		for _, kv := range os.Environ() {
+			i := strings.IndexByte(kv, '=')
+			if i < 0 || !strings.HasPrefix(strings.ToUpper(kv[:i]), prefix) || i == len(prefix) {
+				continue
+			}
+			key := strings.ToLower(kv[len(prefix):i])
+			setConfig(m, strings.Split(key, "__"), kv[i+1:])
+		}
+		return m, nil
+	})
+}
+
+// ConfigFlags returns a source reads flags set in command line, dot
+// separates nested keys, like -mysql.master.host. flag.CommandLine is used if fs is nil
+func ConfigFlags(fs *flag.FlagSet) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]interface{}, error) {
+		if fs == nil {
+			fs = flag.CommandLine
+		}
+		if !fs.Parsed() {
+			return nil, errors.New("config: flags are not parsed")
+		}
+		m := make(map[string]interface{})
+		fs.Visit(func(f *flag.Flag) {
+			setConfig(m, strings.Split(strings.ToLower(f.Name), "."), f.Value.String())
+		})
+		return m, nil
+	})
+}
+
+// ConfigMap returns a source of the map, for defaults or tests
+func ConfigMap(m map[string]interface{}) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]interface{}, error) {
+		return normalizeConfig(m).(map[string]interface{}), nil
+	})
+}
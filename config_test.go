@@ -0,0 +1,258 @@
+package nice
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type configServer struct {
+	Host    string        `yaml:"host" default:"0.0.0.0"`
+	Port    int           `yaml:"port" default:"80"`
+	Timeout time.Duration `yaml:"timeout" default:"10s"`
+	Tags    []string      `yaml:"tags"`
+}
+
+func TestConfig1(t *testing.T) {
+	Convey("config sources", t, func() {
+		Convey("yaml and overlay of environment", func() {
+			env := Env
+			Env = TEST
+			defer func() { Env = env }()
+
+			c := NewConfig(ConfigFile("_fixture/config/app.yaml"))
+			So(c.Load(), ShouldBeNil)
+			So(c.String("name"), ShouldEqual, "nice")
+			So(c.Bool("debug"), ShouldBeTrue)
+			So(c.String("mysql.database"), ShouldEqual, "nice_test")
+			So(c.String("MYSQL.master.host"), ShouldEqual, "127.0.0.1:3306")
+			So(c.Int("mysql.master.maxopen"), ShouldEqual, 10)
+			So(c.Has("mysql.slave"), ShouldBeFalse)
+
+			var mc MysqlConf
+			So(c.Decode("mysql", &mc), ShouldBeNil)
+			So(mc.Database, ShouldEqual, "nice_test")
+			So(mc.Charset, ShouldEqual, "utf8mb4")
+			So(mc.Master.User, ShouldEqual, "root")
+		})
+		Convey("json", func() {
+			c := NewConfig(ConfigFile("_fixture/config/app.json"))
+			So(c.Load(), ShouldBeNil)
+			var s configServer
+			So(c.Decode("server", &s), ShouldBeNil)
+			So(s, ShouldResemble, configServer{Host: "0.0.0.0", Port: 8080, Timeout: 5 * time.Second})
+		})
+		Convey("toml", func() {
+			c := NewConfig(ConfigFile("_fixture/config/app.toml"))
+			So(c.Load(), ShouldBeNil)
+			So(c.String("name"), ShouldEqual, "nice-toml")
+			So(c.Get("tags"), ShouldResemble, []interface{}{"web", "api"})
+			So(c.Int("server.port"), ShouldEqual, 8080)
+			So(c.Float("server.ratio"), ShouldEqual, 0.5)
+			So(c.Bool("server.enabled"), ShouldBeTrue)
+			So(c.Get("server.started"), ShouldEqual, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+			So(c.Int("server.limits.max"), ShouldEqual, 10)
+			So(c.Int("server.limits.burst.size"), ShouldEqual, 0)
+			So(c.Get("server.limits").(map[string]interface{})["burst.size"], ShouldEqual, 20)
+			So(c.String("server.tls.cert"), ShouldEqual, `C:\cert.pem`)
+			So(c.Get("users").([]interface{}), ShouldHaveLength, 2)
+
+			var s configServer
+			So(c.Decode("server", &s), ShouldBeNil)
+			So(s.Timeout, ShouldEqual, 3*time.Second)
+		})
+		Convey("toml spec", func() {
+			dir, _ := ioutil.TempDir("", "nice")
+			defer os.RemoveAll(dir)
+			load := func(content string) (*Config, error) {
+				path := filepath.Join(dir, "spec.toml")
+				ioutil.WriteFile(path, []byte(content), 0644)
+				c := NewConfig(ConfigFile(path))
+				return c, c.Load()
+			}
+
+			c, err := load("s = \"\"\"\na \\\n  b\"\"\"\nt = 07:32:00\n\"a=b\" = 1\n")
+			So(err, ShouldBeNil)
+			So(c.String("s"), ShouldEqual, "a b")
+			So(c.Get("t"), ShouldNotBeNil)
+			So(c.Int("a=b"), ShouldEqual, 1)
+
+			_, err = load("a = 010\n")
+			So(err, ShouldNotBeNil)
+			_, err = load("[t]\na = 1\n[t]\nb = 2\n")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("env and flags override files", func() {
+			os.Setenv("NICETEST_MYSQL__MASTER__HOST", "db:3306")
+			os.Setenv("NICETEST_NAME", "env")
+			defer os.Unsetenv("NICETEST_MYSQL__MASTER__HOST")
+			defer os.Unsetenv("NICETEST_NAME")
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("name", "", "")
+			fs.Int("redis.index", 0, "")
+			fs.Parse([]string{"-redis.index=3"})
+
+			c := NewConfig(
+				ConfigMap(map[string]interface{}{"redis": map[string]interface{}{"maxidle": 5}}),
+				ConfigFile("_fixture/config/app.yaml"),
+				ConfigEnv("NICETEST"),
+				ConfigFlags(fs),
+			)
+			So(c.Load(), ShouldBeNil)
+			So(c.String("name"), ShouldEqual, "env")
+			So(c.String("mysql.master.host"), ShouldEqual, "db:3306")
+			So(c.String("mysql.master.user"), ShouldEqual, "root")
+
+			var rc RedisNode
+			So(c.Decode("redis", &rc), ShouldBeNil)
+			So(rc, ShouldResemble, RedisNode{Host: "127.0.0.1:6379", Index: 3, MaxIdle: 5})
+		})
+		Convey("errors", func() {
+			So(NewConfig(ConfigFile("_fixture/config/none.yaml")).Load(), ShouldNotBeNil)
+			So(NewConfig(ConfigFileOptional("_fixture/config/none.yaml")).Load(), ShouldBeNil)
+			So(NewConfig(ConfigFile("_fixture/index1.html")).Load(), ShouldNotBeNil)
+			So(NewConfig(ConfigFlags(flag.NewFlagSet("test", flag.ContinueOnError))).Load(), ShouldNotBeNil)
+
+			dir, _ := ioutil.TempDir("", "nice")
+			defer os.RemoveAll(dir)
+			for name, content := range map[string]string{
+				"bad.yaml": "a: [1",
+				"bad.json": "{",
+				"bad.toml": "a = [1,",
+			} {
+				path := filepath.Join(dir, name)
+				ioutil.WriteFile(path, []byte(content), 0644)
+				So(NewConfig(ConfigFile(path)).Load(), ShouldNotBeNil)
+			}
+
+			c := NewConfig(ConfigMap(map[string]interface{}{"server": map[string]interface{}{"port": "abc"}}))
+			So(c.Load(), ShouldBeNil)
+			var s configServer
+			So(c.Decode("server", &s), ShouldNotBeNil)
+
+			var errs []error
+			c.OnError(func(err error) {
+				errs = append(errs, err)
+			})
+			So(c.Int("server.port"), ShouldEqual, 0)
+			So(c.Int("server.none"), ShouldEqual, 0)
+			So(errs, ShouldHaveLength, 1)
+		})
+	})
+}
+
+func TestConfig2(t *testing.T) {
+	Convey("application config", t, func() {
+		b2 := New()
+		So(b2.LoadConfig(ConfigFile("_fixture/config/none.yaml")), ShouldNotBeNil)
+		So(b2.LoadConfig(ConfigFile("_fixture/config/app.yaml")), ShouldBeNil)
+		So(b2.Conf["name"], ShouldEqual, "nice")
+		So(b2.Config().String("redis.host"), ShouldEqual, "127.0.0.1:6379")
+		legacy := LoadConfig("_fixture/config/app.yaml")
+		So(legacy["name"], ShouldEqual, "nice")
+		So(legacy["mysql"].(map[interface{}]interface{})["master"], ShouldHaveSameTypeAs, map[interface{}]interface{}{})
+
+		var rc RedisNode
+		So(decodeConf(b2.Conf["redis"], &rc), ShouldBeNil)
+		So(rc.Index, ShouldEqual, 1)
+		rc = RedisNode{}
+		So(decodeConf(&RedisNode{Index: 2}, &rc), ShouldBeNil)
+		So(rc, ShouldResemble, RedisNode{Host: "127.0.0.1:6379", Index: 2})
+		So(decodeConf(nil, &rc), ShouldNotBeNil)
+		_, err := OpenMysql(nil)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestConfig3(t *testing.T) {
+	Convey("reload config", t, func() {
+		dir, _ := ioutil.TempDir("", "nice")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "app.yaml")
+		ioutil.WriteFile(path, []byte("log:\n  level: info\nredis:\n  maxopen: 10\n"), 0644)
+
+		b2 := New()
+		b2.ValidateConfig(func(c *Config) error {
+			if c.Int("redis.maxopen") <= 0 {
+				return errors.New("redis.maxopen must be positive")
+			}
+			return nil
+		})
+		So(b2.LoadConfig(ConfigFile(path)), ShouldBeNil)
+		So(b2.Config().String("log.level"), ShouldEqual, "info")
+
+		changes := make(chan [2]interface{}, 10)
+		b2.OnConfigChange("log.level", func(old, new interface{}) {
+			changes <- [2]interface{}{old, new}
+		})
+		b2.OnConfigChange("redis", func(old, new interface{}) {
+			changes <- [2]interface{}{"redis", new}
+		})
+
+		Convey("load again", func() {
+			ioutil.WriteFile(path, []byte("log:\n  level: debug\nredis:\n  maxopen: 10\n"), 0644)
+			So(b2.LoadConfig(ConfigFile(path)), ShouldBeNil)
+			So(<-changes, ShouldResemble, [2]interface{}{"info", "debug"})
+			So(b2.Conf["log"], ShouldResemble, map[string]interface{}{"level": "debug"})
+			So(changes, ShouldBeEmpty)
+
+			ioutil.WriteFile(path, []byte("log:\n  level: warn\nredis:\n  maxopen: 0\n"), 0644)
+			So(b2.LoadConfig(ConfigFile(path)), ShouldNotBeNil)
+			So(b2.Config().String("log.level"), ShouldEqual, "debug")
+			So(changes, ShouldBeEmpty)
+		})
+		Convey("watch files", func() {
+			r := &Redis{active: 10}
+			r.Open()
+			r.ResizeOnChange(b2.Config(), "redis")
+			active := func() int {
+				r.mutex.RLock()
+				defer r.mutex.RUnlock()
+				return r.active
+			}
+			b2.WatchConfig(10 * time.Millisecond)
+			defer b2.Config().StopWatch()
+			errs := make(chan error, 10)
+			b2.Config().OnError(func(err error) {
+				errs <- err
+			})
+
+			ioutil.WriteFile(path, []byte("log:\n  level: error\nredis:\n  maxopen: 20\n"), 0644)
+			var got [][2]interface{}
+			for i := 0; i < 2; i++ {
+				select {
+				case change := <-changes:
+					got = append(got, change)
+				case <-time.After(2 * time.Second):
+				}
+			}
+			So(got, ShouldResemble, [][2]interface{}{
+				{"info", "error"},
+				{"redis", map[string]interface{}{"maxopen": 20}},
+			})
+			So(b2.Config().Int("redis.maxopen"), ShouldEqual, 20)
+			So(active(), ShouldEqual, 20)
+			So(b2.Conf["log"], ShouldResemble, map[string]interface{}{"level": "info"})
+
+			// rejected by validator
+			time.Sleep(20 * time.Millisecond)
+			ioutil.WriteFile(path, []byte("log:\n  level: warn\nredis:\n  maxopen: 0\n"), 0644)
+			select {
+			case err := <-errs:
+				So(err.Error(), ShouldContainSubstring, "redis.maxopen must be positive")
+			case <-time.After(2 * time.Second):
+				So("no error", ShouldBeEmpty)
+			}
+			So(b2.Config().String("log.level"), ShouldEqual, "error")
+			So(active(), ShouldEqual, 20)
+			So(changes, ShouldBeEmpty)
+		})
+	})
+}
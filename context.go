@@ -2,6 +2,7 @@ package nice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -16,6 +17,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -24,8 +27,40 @@ var (
 
 	// ErrXMLPayloadEmpty is returned when the XML payload is empty.
 	ErrXMLPayloadEmpty = errors.New("XML payload is empty")
+
+	// ErrValueMissing is returned when the request value is not present.
+	ErrValueMissing = errors.New("value is missing")
 )
 
+// request value sources of ParamError
+const (
+	SourceParam  = "param"
+	SourceQuery  = "query"
+	SourceCookie = "cookie"
+)
+
+// ParamError is returned by the E accessors when a request value is missing
+// or cannot be parsed, the default error handler responds it with 400 Bad Request.
+type ParamError struct {
+	Source string // param, query or cookie
+	Name   string
+	Value  string
+	Err    error
+}
+
+// Error implements error interface
+func (e *ParamError) Error() string {
+	if e.Err == ErrValueMissing {
+		return fmt.Sprintf("%s %s is missing", e.Source, e.Name)
+	}
+	return fmt.Sprintf("%s %s %q is invalid: %v", e.Source, e.Name, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error, like ErrValueMissing or *strconv.NumError
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
 const (
 	// defaultMaxMemory Maximum amount of memory to use when parsing a multipart form.
 	// Set this to whatever value you prefer; default is 32 MB.
@@ -48,6 +83,9 @@ const (
 	TextPlain                        = "text/plain"
 	TextPlainCharsetUTF8             = TextPlain + "; " + CharsetUTF8
 	MultipartForm                    = "multipart/form-data"
+
+	// HeaderXRequestID is the header of request id
+	HeaderXRequestID = "X-Request-ID"
 )
 
 // Context provlider a HTTP context for nice
@@ -59,10 +97,15 @@ type Context struct {
 	store      map[string]interface{}
 	storeMutex sync.RWMutex  // store rw lock
 	routeName  string        // route name
+	route      *Node         // matched route node
 	pNames     []string      // route params names
 	pValues    []string      // route params values
+	pTyped     []interface{} // route params typed values by constraint
 	handlers   []HandlerFunc // middleware handler and route match handler
 	hi         int           // handlers execute position
+	err        error         // error handled by Error
+	scope      *Scope        // request scope of DI
+	requestID  string        // request id set by SetRequestID
 	uid        uint32        //login member id
 }
 
@@ -73,6 +116,7 @@ func NewContext(w http.ResponseWriter, r *http.Request, n *Nice) *Context {
 	c.nice = n
 	c.pNames = make([]string, 0, 16)
 	c.pValues = make([]string, 0, 16)
+	c.pTyped = make([]interface{}, 0, 16)
 	c.handlers = make([]HandlerFunc, len(n.middleware), len(n.middleware)+3)
 	copy(c.handlers, n.middleware)
 	c.Reset(w, r)
@@ -84,16 +128,60 @@ func (c *Context) RouteName() string {
 	return c.routeName
 }
 
+// Route returns the matched route node, nil if no route matched
+func (c *Context) Route() *Node {
+	return c.route
+}
+
+// RouteMeta returns metadata of the matched route, nil if not set
+func (c *Context) RouteMeta(key string) interface{} {
+	if c.route == nil {
+		return nil
+	}
+	return c.route.GetMeta(key)
+}
+
+// RoutePattern returns pattern of the matched route, like /users/:id
+func (c *Context) RoutePattern() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.pattern
+}
+
+// Ctx returns the context.Context of the request, it carries deadline,
+// cancellation when client disconnects and other request scoped values.
+func (c *Context) Ctx() context.Context {
+	if c.Req == nil {
+		return context.Background()
+	}
+	return c.Req.Context()
+}
+
+// WithContext replace the context.Context of the request,
+// handlers executed later will receive the new context by c.Ctx().
+func (c *Context) WithContext(ctx context.Context) {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c.Req = c.Req.WithContext(ctx)
+}
+
 // Reset ...
 func (c *Context) Reset(w http.ResponseWriter, r *http.Request) {
 	c.Resp.reset(w)
 	c.Req = r
 	c.hi = 0
+	c.err = nil
+	c.scope = nil
+	c.requestID = ""
 	c.uid = 0
 	c.handlers = c.handlers[:len(c.nice.middleware)]
 	c.routeName = ""
+	c.route = nil
 	c.pNames = c.pNames[:0]
 	c.pValues = c.pValues[:0]
+	c.pTyped = c.pTyped[:0]
 	c.storeMutex.Lock()
 	c.store = nil
 	c.storeMutex.Unlock()
@@ -132,8 +220,31 @@ func (c *Context) Gets() map[string]interface{} {
 
 // SetParam read route param value from uri
 func (c *Context) SetParam(name, value string) {
+	c.setParam(name, value, nil)
+}
+
+// setParam set route param with typed value parsed by route constraint
+func (c *Context) setParam(name, value string, typed interface{}) {
 	c.pNames = append(c.pNames, name)
 	c.pValues = append(c.pValues, value)
+	c.pTyped = append(c.pTyped, typed)
+}
+
+// truncateParams removes route params after n, used when route match backtracks
+func (c *Context) truncateParams(n int) {
+	c.pNames = c.pNames[:n]
+	c.pValues = c.pValues[:n]
+	c.pTyped = c.pTyped[:n]
+}
+
+// paramTyped returns typed value of route param which has a typed constraint
+func (c *Context) paramTyped(name string) interface{} {
+	for i := len(c.pNames) - 1; i >= 0; i-- {
+		if c.pNames[i] == name {
+			return c.pTyped[i]
+		}
+	}
+	return nil
 }
 
 // Param get route param from context
@@ -155,8 +266,15 @@ func (c *Context) Params() map[string]string {
 	return m
 }
 
-// ParamInt get route param from context and format to int
+// ParamInt get route param from context and format to int,
+// the value validated by <int> or <uint> constraint is not parsed again
 func (c *Context) ParamInt(name string) int {
+	switch v := c.paramTyped(name).(type) {
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	}
 	v, _ := strconv.Atoi(c.Param(name))
 	return v
 }
@@ -183,12 +301,26 @@ func (c *Context) ParamUint8(name string) uint8 {
 
 // ParamInt64 get route param from context and format to int64
 func (c *Context) ParamInt64(name string) int64 {
+	switch v := c.paramTyped(name).(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	}
 	v, _ := strconv.ParseInt(c.Param(name), 10, 64)
 	return v
 }
 
 // ParamFloat get route param from context and format to float64
 func (c *Context) ParamFloat(name string) float64 {
+	switch v := c.paramTyped(name).(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
 	v, _ := strconv.ParseFloat(c.Param(name), 64)
 	return v
 }
@@ -199,6 +331,115 @@ func (c *Context) ParamBool(name string) bool {
 	return v
 }
 
+// ParamIntE get route param from context and format to int, returns *ParamError if missing or invalid
+func (c *Context) ParamIntE(name string) (int, error) {
+	v, err := c.parseInt(SourceParam, name, strconv.IntSize)
+	return int(v), err
+}
+
+// ParamInt32E get route param from context and format to int32, returns *ParamError if missing or invalid
+func (c *Context) ParamInt32E(name string) (int32, error) {
+	v, err := c.parseInt(SourceParam, name, 32)
+	return int32(v), err
+}
+
+// ParamInt64E get route param from context and format to int64, returns *ParamError if missing or invalid
+func (c *Context) ParamInt64E(name string) (int64, error) {
+	return c.parseInt(SourceParam, name, 64)
+}
+
+// ParamUint32E get route param from context and format to uint32, returns *ParamError if missing or invalid
+func (c *Context) ParamUint32E(name string) (uint32, error) {
+	v, err := c.parseUint(SourceParam, name, 32)
+	return uint32(v), err
+}
+
+// ParamUint16E get route param from context and format to uint16, returns *ParamError if missing or invalid
+func (c *Context) ParamUint16E(name string) (uint16, error) {
+	v, err := c.parseUint(SourceParam, name, 16)
+	return uint16(v), err
+}
+
+// ParamUint8E get route param from context and format to uint8, returns *ParamError if missing or invalid
+func (c *Context) ParamUint8E(name string) (uint8, error) {
+	v, err := c.parseUint(SourceParam, name, 8)
+	return uint8(v), err
+}
+
+// ParamFloatE get route param from context and format to float64, returns *ParamError if missing or invalid
+func (c *Context) ParamFloatE(name string) (float64, error) {
+	return c.parseFloat(SourceParam, name)
+}
+
+// ParamBoolE get route param from context and format to bool, returns *ParamError if missing or invalid
+func (c *Context) ParamBoolE(name string) (bool, error) {
+	return c.parseBool(SourceParam, name)
+}
+
+// ParamIntDefault get route param from context and format to int, returns def if missing or invalid
+func (c *Context) ParamIntDefault(name string, def int) int {
+	if v, err := c.ParamIntE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamInt32Default get route param from context and format to int32, returns def if missing or invalid
+func (c *Context) ParamInt32Default(name string, def int32) int32 {
+	if v, err := c.ParamInt32E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamInt64Default get route param from context and format to int64, returns def if missing or invalid
+func (c *Context) ParamInt64Default(name string, def int64) int64 {
+	if v, err := c.ParamInt64E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamUint32Default get route param from context and format to uint32, returns def if missing or invalid
+func (c *Context) ParamUint32Default(name string, def uint32) uint32 {
+	if v, err := c.ParamUint32E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamUint16Default get route param from context and format to uint16, returns def if missing or invalid
+func (c *Context) ParamUint16Default(name string, def uint16) uint16 {
+	if v, err := c.ParamUint16E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamUint8Default get route param from context and format to uint8, returns def if missing or invalid
+func (c *Context) ParamUint8Default(name string, def uint8) uint8 {
+	if v, err := c.ParamUint8E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamFloatDefault get route param from context and format to float64, returns def if missing or invalid
+func (c *Context) ParamFloatDefault(name string, def float64) float64 {
+	if v, err := c.ParamFloatE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamBoolDefault get route param from context and format to bool, returns def if missing or invalid
+func (c *Context) ParamBoolDefault(name string, def bool) bool {
+	if v, err := c.ParamBoolE(name); err == nil {
+		return v
+	}
+	return def
+}
+
 // Query get a param from http.Request.Form
 func (c *Context) Query(name string) string {
 	c.ParseForm(0)
@@ -274,6 +515,115 @@ func (c *Context) QueryBool(name string) bool {
 	return v
 }
 
+// QueryIntE get a param from http.Request.Form and format to int, returns *ParamError if missing or invalid
+func (c *Context) QueryIntE(name string) (int, error) {
+	v, err := c.parseInt(SourceQuery, name, strconv.IntSize)
+	return int(v), err
+}
+
+// QueryInt32E get a param from http.Request.Form and format to int32, returns *ParamError if missing or invalid
+func (c *Context) QueryInt32E(name string) (int32, error) {
+	v, err := c.parseInt(SourceQuery, name, 32)
+	return int32(v), err
+}
+
+// QueryInt64E get a param from http.Request.Form and format to int64, returns *ParamError if missing or invalid
+func (c *Context) QueryInt64E(name string) (int64, error) {
+	return c.parseInt(SourceQuery, name, 64)
+}
+
+// QueryUint32E get a param from http.Request.Form and format to uint32, returns *ParamError if missing or invalid
+func (c *Context) QueryUint32E(name string) (uint32, error) {
+	v, err := c.parseUint(SourceQuery, name, 32)
+	return uint32(v), err
+}
+
+// QueryUint16E get a param from http.Request.Form and format to uint16, returns *ParamError if missing or invalid
+func (c *Context) QueryUint16E(name string) (uint16, error) {
+	v, err := c.parseUint(SourceQuery, name, 16)
+	return uint16(v), err
+}
+
+// QueryUint8E get a param from http.Request.Form and format to uint8, returns *ParamError if missing or invalid
+func (c *Context) QueryUint8E(name string) (uint8, error) {
+	v, err := c.parseUint(SourceQuery, name, 8)
+	return uint8(v), err
+}
+
+// QueryFloatE get a param from http.Request.Form and format to float64, returns *ParamError if missing or invalid
+func (c *Context) QueryFloatE(name string) (float64, error) {
+	return c.parseFloat(SourceQuery, name)
+}
+
+// QueryBoolE get a param from http.Request.Form and format to bool, returns *ParamError if missing or invalid
+func (c *Context) QueryBoolE(name string) (bool, error) {
+	return c.parseBool(SourceQuery, name)
+}
+
+// QueryIntDefault get a param from http.Request.Form and format to int, returns def if missing or invalid
+func (c *Context) QueryIntDefault(name string, def int) int {
+	if v, err := c.QueryIntE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryInt32Default get a param from http.Request.Form and format to int32, returns def if missing or invalid
+func (c *Context) QueryInt32Default(name string, def int32) int32 {
+	if v, err := c.QueryInt32E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryInt64Default get a param from http.Request.Form and format to int64, returns def if missing or invalid
+func (c *Context) QueryInt64Default(name string, def int64) int64 {
+	if v, err := c.QueryInt64E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryUint32Default get a param from http.Request.Form and format to uint32, returns def if missing or invalid
+func (c *Context) QueryUint32Default(name string, def uint32) uint32 {
+	if v, err := c.QueryUint32E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryUint16Default get a param from http.Request.Form and format to uint16, returns def if missing or invalid
+func (c *Context) QueryUint16Default(name string, def uint16) uint16 {
+	if v, err := c.QueryUint16E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryUint8Default get a param from http.Request.Form and format to uint8, returns def if missing or invalid
+func (c *Context) QueryUint8Default(name string, def uint8) uint8 {
+	if v, err := c.QueryUint8E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryFloatDefault get a param from http.Request.Form and format to float64, returns def if missing or invalid
+func (c *Context) QueryFloatDefault(name string, def float64) float64 {
+	if v, err := c.QueryFloatE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// QueryBoolDefault get a param from http.Request.Form and format to bool, returns def if missing or invalid
+func (c *Context) QueryBoolDefault(name string, def bool) bool {
+	if v, err := c.QueryBoolE(name); err == nil {
+		return v
+	}
+	return def
+}
+
 // Querys return http.Request.URL queryString data
 func (c *Context) Querys() map[string]interface{} {
 	params := make(map[string]interface{})
@@ -457,6 +807,147 @@ func (c *Context) GetCookieBool(name string) bool {
 	return v
 }
 
+// GetCookieIntE returns cookie result in int type, returns *ParamError if missing or invalid
+func (c *Context) GetCookieIntE(name string) (int, error) {
+	v, err := c.parseInt(SourceCookie, name, strconv.IntSize)
+	return int(v), err
+}
+
+// GetCookieInt32E returns cookie result in int32 type, returns *ParamError if missing or invalid
+func (c *Context) GetCookieInt32E(name string) (int32, error) {
+	v, err := c.parseInt(SourceCookie, name, 32)
+	return int32(v), err
+}
+
+// GetCookieInt64E returns cookie result in int64 type, returns *ParamError if missing or invalid
+func (c *Context) GetCookieInt64E(name string) (int64, error) {
+	return c.parseInt(SourceCookie, name, 64)
+}
+
+// GetCookieFloat64E returns cookie result in float64 type, returns *ParamError if missing or invalid
+func (c *Context) GetCookieFloat64E(name string) (float64, error) {
+	return c.parseFloat(SourceCookie, name)
+}
+
+// GetCookieBoolE returns cookie result in bool type, returns *ParamError if missing or invalid
+func (c *Context) GetCookieBoolE(name string) (bool, error) {
+	return c.parseBool(SourceCookie, name)
+}
+
+// GetCookieIntDefault returns cookie result in int type, returns def if missing or invalid
+func (c *Context) GetCookieIntDefault(name string, def int) int {
+	if v, err := c.GetCookieIntE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetCookieInt32Default returns cookie result in int32 type, returns def if missing or invalid
+func (c *Context) GetCookieInt32Default(name string, def int32) int32 {
+	if v, err := c.GetCookieInt32E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetCookieInt64Default returns cookie result in int64 type, returns def if missing or invalid
+func (c *Context) GetCookieInt64Default(name string, def int64) int64 {
+	if v, err := c.GetCookieInt64E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetCookieFloat64Default returns cookie result in float64 type, returns def if missing or invalid
+func (c *Context) GetCookieFloat64Default(name string, def float64) float64 {
+	if v, err := c.GetCookieFloat64E(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetCookieBoolDefault returns cookie result in bool type, returns def if missing or invalid
+func (c *Context) GetCookieBoolDefault(name string, def bool) bool {
+	if v, err := c.GetCookieBoolE(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// lookup returns request value from the given source and whether it is present
+func (c *Context) lookup(source, name string) (string, bool) {
+	switch source {
+	case SourceParam:
+		for i := len(c.pNames) - 1; i >= 0; i-- {
+			if c.pNames[i] == name {
+				return c.pValues[i], true
+			}
+		}
+	case SourceQuery:
+		c.ParseForm(0)
+		if v, ok := c.Req.Form[name]; ok && len(v) > 0 {
+			return v[0], true
+		}
+	case SourceCookie:
+		if _, err := c.Req.Cookie(name); err == nil {
+			return c.GetCookie(name), true
+		}
+	}
+	return "", false
+}
+
+// parseInt parses request value to int64 with bit size
+func (c *Context) parseInt(source, name string, bitSize int) (int64, error) {
+	s, ok := c.lookup(source, name)
+	if !ok {
+		return 0, &ParamError{Source: source, Name: name, Err: ErrValueMissing}
+	}
+	v, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		return 0, &ParamError{Source: source, Name: name, Value: s, Err: err}
+	}
+	return v, nil
+}
+
+// parseUint parses request value to uint64 with bit size
+func (c *Context) parseUint(source, name string, bitSize int) (uint64, error) {
+	s, ok := c.lookup(source, name)
+	if !ok {
+		return 0, &ParamError{Source: source, Name: name, Err: ErrValueMissing}
+	}
+	v, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		return 0, &ParamError{Source: source, Name: name, Value: s, Err: err}
+	}
+	return v, nil
+}
+
+// parseFloat parses request value to float64
+func (c *Context) parseFloat(source, name string) (float64, error) {
+	s, ok := c.lookup(source, name)
+	if !ok {
+		return 0, &ParamError{Source: source, Name: name, Err: ErrValueMissing}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, &ParamError{Source: source, Name: name, Value: s, Err: err}
+	}
+	return v, nil
+}
+
+// parseBool parses request value to bool
+func (c *Context) parseBool(source, name string) (bool, error) {
+	s, ok := c.lookup(source, name)
+	if !ok {
+		return false, &ParamError{Source: source, Name: name, Err: ErrValueMissing}
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &ParamError{Source: source, Name: name, Value: s, Err: err}
+	}
+	return v, nil
+}
+
 // String write text by string
 func (c *Context) String(code int, s string) {
 	c.Resp.Header().Set("Content-Type", TextPlainCharsetUTF8)
@@ -617,6 +1108,38 @@ func (c *Context) UserAgent() string {
 
 // URL returns http request full url
 func (c *Context) URL(hasQuery bool) string {
+	base := c.baseURL()
+	if hasQuery {
+		url := c.Req.RequestURI
+		if url == "" {
+			url = c.Req.URL.Path
+			if len(c.Req.URL.RawQuery) > 0 {
+				url += "?" + c.Req.URL.RawQuery
+			}
+		}
+		return base + url
+	}
+	return base + c.Req.URL.Path
+}
+
+// AbsURLFor use named route return absolute url with scheme and host of the request,
+// returns empty if error, see Nice.URLForE
+func (c *Context) AbsURLFor(name string, args ...interface{}) string {
+	url, _ := c.AbsURLForE(name, args...)
+	return url
+}
+
+// AbsURLForE use named route return absolute url with scheme and host of the request
+func (c *Context) AbsURLForE(name string, args ...interface{}) (string, error) {
+	url, err := c.nice.URLForE(name, args...)
+	if err != nil {
+		return "", err
+	}
+	return c.baseURL() + url, nil
+}
+
+// baseURL returns scheme and host of the request
+func (c *Context) baseURL() string {
 	scheme := c.Req.URL.Scheme
 	host := c.Req.URL.Host
 	if scheme == "" {
@@ -640,17 +1163,7 @@ func (c *Context) URL(hasQuery bool) string {
 	} else {
 		scheme = ""
 	}
-	if hasQuery {
-		url := c.Req.RequestURI
-		if url == "" {
-			url = c.Req.URL.Path
-			if len(c.Req.URL.RawQuery) > 0 {
-				url += "?" + c.Req.URL.RawQuery
-			}
-		}
-		return scheme + host + url
-	}
-	return scheme + host + c.Req.URL.Path
+	return scheme + host
 }
 
 // IsMobile returns if it is a mobile phone device request
@@ -718,6 +1231,12 @@ func (c *Context) Error(err error) {
 	c.nice.Error(err, c)
 }
 
+// Err returns the last error handled by Error or returned by a HandlerFuncE,
+// middleware can inspect it after c.Next().
+func (c *Context) Err() error {
+	return c.err
+}
+
 // NotFound invokes the registered HTTP NotFound handler.
 func (c *Context) NotFound() {
 	c.nice.NotFound(c)
@@ -733,6 +1252,142 @@ func (c *Context) DI(name string) interface{} {
 	return c.nice.GetDI(name)
 }
 
+// requestIDKey is the key of request id in context.Context
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carries the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id carried by ctx, empty if none,
+// it is used to pass request id to services, like the gRPC dialer.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID returns the request id set by SetRequestID, otherwise the
+// X-Request-ID header of request if it is valid, see ValidRequestID.
+func (c *Context) RequestID() string {
+	if c.requestID != "" {
+		return c.requestID
+	}
+	if id := c.Req.Header.Get(HeaderXRequestID); ValidRequestID(id) {
+		return id
+	}
+	return ""
+}
+
+// maxRequestIDLen is the max length of request id from client
+const maxRequestIDLen = 128
+
+// ValidRequestID checks id is not empty, not too long and only has visible ascii chars,
+// so the request id from client is safe to log and echo.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRequestID stores the request id, it is also carried by c.Ctx()
+// and echoed in X-Request-ID header of response.
+func (c *Context) SetRequestID(id string) {
+	c.requestID = id
+	c.WithContext(WithRequestID(c.Ctx(), id))
+	c.Resp.Header().Set(HeaderXRequestID, id)
+}
+
+// Log returns the leveled logger of nice with fields of the request,
+// request_id, trace_id, route and remote_addr.
+//
+// Example:
+//	c.Log().Info("user login", "uid", uid)
+func (c *Context) Log() LevelLogger {
+	kv := make([]interface{}, 0, 8)
+	if id := c.RequestID(); id != "" {
+		kv = append(kv, "request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(c.Ctx()); sc.IsValid() {
+		kv = append(kv, "trace_id", sc.TraceID().String())
+	}
+	route := c.routeName
+	if route == "" {
+		route = c.RoutePattern()
+	}
+	if route != "" {
+		kv = append(kv, "route", route)
+	}
+	kv = append(kv, "remote_addr", c.RemoteAddr())
+	return c.nice.Log().With(kv...)
+}
+
+// Scope returns the request scope of DI, it is created when first called, the
+// context is set into it. scoped instances are resolved once in the request,
+// and closed with c.Err() before the response is written, or when the handler
+// chain ends if nothing is written. a transaction is committed if no error,
+// rolled back if error or panic, the response is an error if commit failed.
+// so scoped instances should not be used after writing the response.
+//
+// Example:
+//	app.Provide(func(db *nice.Mysql, c *nice.Context) (*nice.SQLConnTransaction, error) {
+//		return db.BeginContext(c.Ctx())
+//	}, nice.Scoped)
+//	app.Post("/orders", nice.HandlerE(func(c *nice.Context) error {
+//		var tx *nice.SQLConnTransaction
+//		if err := c.Scope().Resolve(&tx); err != nil {
+//			return err
+//		}
+//		_, err := tx.Insert("INSERT INTO orders ...")
+//		return err
+//	}))
+func (c *Context) Scope() *Scope {
+	if c.scope == nil {
+		d, ok := c.nice.di.(diProvider)
+		if !ok {
+			panic(ErrDIUnsupported)
+		}
+		c.scope = d.NewScope()
+		c.scope.Set(c)
+		c.Resp.Before(c.commitScope)
+	}
+	return c.scope
+}
+
+// commitScope closes the request scope before the response is written,
+// the error handler writes the response instead if closing failed.
+func (c *Context) commitScope() {
+	if c.scope == nil {
+		return
+	}
+	s, err := c.scope, c.err
+	c.scope = nil
+	if e := s.CloseWithError(err); e != nil {
+		if err != nil {
+			c.nice.Logger().Printf("Close request scope failed: %v", e)
+			return
+		}
+		c.Error(e)
+	}
+}
+
+// closeScope closes the request scope with err
+func (c *Context) closeScope(err error) {
+	if c.scope == nil {
+		return
+	}
+	if e := c.scope.CloseWithError(err); e != nil {
+		c.nice.Logger().Printf("Close request scope failed: %v", e)
+	}
+	c.scope = nil
+}
+
 // Set uid in context
 func (c *Context) SetUid(v uint32) {
 	c.uid = v
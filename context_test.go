@@ -2,8 +2,10 @@ package nice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -14,6 +16,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -613,6 +616,139 @@ func TestContextNice1(t *testing.T) {
 	})
 }
 
+func TestContextValueE1(t *testing.T) {
+	Convey("context typed accessors with error", t, func() {
+		Convey("param", func() {
+			n.Get("/context/e1/:id/:flag", func(c *Context) {
+				id, err := c.ParamIntE("id")
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, 123)
+				u8, err := c.ParamUint8E("id")
+				So(u8, ShouldEqual, 123)
+				_, err = c.ParamBoolE("flag")
+				So(err, ShouldNotBeNil)
+				So(err.(*ParamError).Source, ShouldEqual, SourceParam)
+				_, err = c.ParamInt64E("none")
+				So(err.(*ParamError).Err, ShouldEqual, ErrValueMissing)
+				So(errors.Is(err, ErrValueMissing), ShouldBeTrue)
+				So(c.ParamBoolDefault("flag", true), ShouldBeTrue)
+				So(c.ParamInt32Default("id", 1), ShouldEqual, 123)
+			})
+			w := request("GET", "/context/e1/123/x")
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+		Convey("query", func() {
+			n.Get("/context/e2", func(c *Context) {
+				_, err := c.QueryUint8E("big")
+				So(err, ShouldNotBeNil)
+				f, err := c.QueryFloatE("f")
+				So(err, ShouldBeNil)
+				So(f, ShouldEqual, 1.5)
+				So(c.QueryIntDefault("page", 1), ShouldEqual, 1)
+				So(c.QueryUint16Default("big", 7), ShouldEqual, 300)
+				So(c.QueryBoolDefault("f", true), ShouldBeTrue)
+			})
+			w := request("GET", "/context/e2?big=300&f=1.5")
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+		Convey("cookie", func() {
+			n.Get("/context/e3", func(c *Context) {
+				v, err := c.GetCookieInt64E("n")
+				So(err, ShouldBeNil)
+				So(v, ShouldEqual, 5)
+				_, err = c.GetCookieBoolE("none")
+				So(err, ShouldNotBeNil)
+				So(c.GetCookieFloat64Default("none", 2.5), ShouldEqual, 2.5)
+			})
+			req, _ := http.NewRequest("GET", "/context/e3", nil)
+			req.AddCookie(&http.Cookie{Name: "n", Value: "5"})
+			w := httptest.NewRecorder()
+			n.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+		Convey("bad request", func() {
+			b2 := New()
+			b2.Get("/e4", func(c *Context) {
+				if _, err := c.QueryIntE("page"); err != nil {
+					c.Error(err)
+					return
+				}
+				c.String(200, "ok")
+			})
+			req, _ := http.NewRequest("GET", "/e4?page=abc", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "query page")
+		})
+	})
+}
+
+type ctxKey string
+
+func TestContextCtx1(t *testing.T) {
+	Convey("context.Context propagation", t, func() {
+		Convey("request context", func() {
+			n.Get("/context/ctx1", func(c *Context) {
+				So(c.Ctx(), ShouldEqual, c.Req.Context())
+				_, ok := c.Ctx().Deadline()
+				So(ok, ShouldBeFalse)
+				c.WithContext(context.WithValue(c.Ctx(), ctxKey("user"), "nice"))
+				c.Next()
+			}, func(c *Context) {
+				So(c.Ctx().Value(ctxKey("user")), ShouldEqual, "nice")
+			})
+			w := request("GET", "/context/ctx1")
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+		Convey("route timeout", func() {
+			n.Get("/context/ctx2", func(c *Context) {
+				_, ok := c.Ctx().Deadline()
+				So(ok, ShouldBeTrue)
+				select {
+				case <-c.Ctx().Done():
+					c.String(http.StatusGatewayTimeout, c.Ctx().Err().Error())
+				case <-time.After(time.Second):
+					c.String(200, "ok")
+				}
+			}).Timeout(10 * time.Millisecond)
+			w := request("GET", "/context/ctx2")
+			So(w.Code, ShouldEqual, http.StatusGatewayTimeout)
+		})
+	})
+}
+
+func TestContextRoute1(t *testing.T) {
+	Convey("route middleware and metadata", t, func() {
+		var order []string
+		mw := func(name string) HandlerFunc {
+			return func(c *Context) {
+				order = append(order, name)
+				c.Next()
+			}
+		}
+		n.Group("/context/route", func() {
+			n.Get("/:id", func(c *Context) {
+				order = append(order, "handler")
+				So(c.RoutePattern(), ShouldEqual, "/context/route/:id")
+				So(c.RouteMeta("scope"), ShouldEqual, "admin")
+				So(c.RouteMeta("none"), ShouldBeNil)
+				So(c.Route().HasTag("user"), ShouldBeTrue)
+				So(c.Route().Description(), ShouldEqual, "get user")
+			}).Use(mw("use1")).Use(mw("use2")).Meta("scope", "admin").Tag("user").Describe("get user").Name("context_route")
+		}, mw("group"))
+		w := request("GET", "/context/route/1")
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(order, ShouldResemble, []string{"group", "use1", "use2", "handler"})
+		So(n.URLFor("context_route", 1), ShouldEqual, "/context/route/1")
+
+		c := NewContext(httptest.NewRecorder(), nil, n)
+		So(c.Route(), ShouldBeNil)
+		So(c.RouteMeta("scope"), ShouldBeNil)
+		So(c.RoutePattern(), ShouldEqual, "")
+	})
+}
+
 // newfileUploadRequest Creates a new file upload http request with optional extra params
 func newfileUploadRequest(uri string, params map[string]string, paramName, path string) (*http.Request, error) {
 	file, err := os.Open(path)
@@ -641,3 +777,127 @@ func newfileUploadRequest(uri string, params map[string]string, paramName, path
 	req.Header.Add("Content-Type", writer.FormDataContentType())
 	return req, err
 }
+
+type scopeTx struct {
+	path   string
+	closed *[]string
+}
+
+func (tx *scopeTx) CloseWithError(err error) error {
+	result := "commit"
+	if err != nil {
+		result = "rollback: " + err.Error()
+	}
+	*tx.closed = append(*tx.closed, tx.path+" "+result)
+	if err == nil && tx.path == "/fail" {
+		return errors.New("commit failed")
+	}
+	return nil
+}
+
+// headerRecorder records the closed scoped instances when header is written
+type headerRecorder struct {
+	*httptest.ResponseRecorder
+	closed *[]string
+	seen   []string
+}
+
+func (w *headerRecorder) WriteHeader(code int) {
+	w.seen = append([]string{}, *w.closed...)
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+type scopeUser interface {
+	ID() int
+}
+
+type scopeMember int
+
+func (m scopeMember) ID() int {
+	return int(m)
+}
+
+func TestContextScope1(t *testing.T) {
+	Convey("request scope", t, func() {
+		var closed []string
+		b2 := New()
+		b2.Provide(func(c *Context) *scopeTx {
+			return &scopeTx{path: c.Req.URL.Path, closed: &closed}
+		}, Scoped)
+		b2.Use(func(c *Context) {
+			c.Scope().Set(scopeMember(7), (*scopeUser)(nil))
+			c.Next()
+		})
+		b2.Get("/ok", HandlerE(func(c *Context) error {
+			var tx1, tx2 *scopeTx
+			if err := c.Scope().Resolve(&tx1); err != nil {
+				return err
+			}
+			return c.Scope().Invoke(func(tx *scopeTx, u scopeUser) {
+				tx2 = tx
+				c.String(200, fmt.Sprintf("%v %d", tx1 == tx2, u.ID()))
+			})
+		}))
+		b2.Get("/error", HandlerE(func(c *Context) error {
+			var tx *scopeTx
+			c.Scope().Resolve(&tx)
+			return NewHTTPError(http.StatusConflict)
+		}))
+		b2.Get("/panic", func(c *Context) {
+			var tx *scopeTx
+			c.Scope().Resolve(&tx)
+			panic("failed")
+		})
+		b2.Get("/none", func(c *Context) {
+			c.String(200, "none")
+		})
+
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+		So(w.Body.String(), ShouldEqual, "true 7")
+
+		b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/error", nil))
+		So(func() {
+			b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+		}, ShouldPanic)
+		b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/none", nil))
+		So(closed, ShouldResemble, []string{
+			"/ok commit",
+			"/error rollback: code=409, message=Conflict",
+			"/panic rollback: handler panic",
+		})
+	})
+}
+
+func TestContextScope2(t *testing.T) {
+	Convey("request scope closed before response", t, func() {
+		var closed []string
+		b2 := New()
+		b2.Provide(func(c *Context) *scopeTx {
+			return &scopeTx{path: c.Req.URL.Path, closed: &closed}
+		}, Scoped)
+		h := func(c *Context) error {
+			var tx *scopeTx
+			if err := c.Scope().Resolve(&tx); err != nil {
+				return err
+			}
+			c.JSON(http.StatusCreated, map[string]string{"path": tx.path})
+			return nil
+		}
+		b2.Post("/ok", HandlerE(h))
+		b2.Post("/fail", HandlerE(h))
+
+		w := &headerRecorder{ResponseRecorder: httptest.NewRecorder(), closed: &closed}
+		b2.ServeHTTP(w, httptest.NewRequest("POST", "/ok", nil))
+		So(w.Code, ShouldEqual, http.StatusCreated)
+		So(w.seen, ShouldResemble, []string{"/ok commit"})
+
+		closed = nil
+		w = &headerRecorder{ResponseRecorder: httptest.NewRecorder(), closed: &closed}
+		b2.ServeHTTP(w, httptest.NewRequest("POST", "/fail", nil))
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(w.Body.String(), ShouldNotContainSubstring, "path")
+		So(w.seen, ShouldResemble, []string{"/fail commit"})
+		So(closed, ShouldResemble, []string{"/fail commit"})
+	})
+}
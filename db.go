@@ -1,6 +1,7 @@
 package nice
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -10,3 +11,16 @@ type Db interface {
 	QueryRow(sqlStr string, args ...interface{}) *sql.Row
 	Exec(sqlStr string, args ...interface{}) (sql.Result, error)
 }
+
+// DbContext is a Db which honors context deadline and cancellation
+type DbContext interface {
+	Db
+	QueryContext(ctx context.Context, sqlStr string, args ...interface{}) ([]map[string]interface{}, error)
+	QueryRowContext(ctx context.Context, sqlStr string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, sqlStr string, args ...interface{}) (sql.Result, error)
+}
+
+// DbStats is a Db reports stats of connection pools, keyed by pool name like master and slave
+type DbStats interface {
+	Stats() map[string]sql.DBStats
+}
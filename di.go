@@ -1,6 +1,11 @@
 package nice
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"sync"
 )
 
@@ -10,10 +15,35 @@ type DIer interface {
 	Get(name string) interface{}
 }
 
-// DI provlider a dependency injection service for nice
+// diCloser is implemented by DIer which can close registered services
+type diCloser interface {
+	Close() error
+}
+
+// diGetter is implemented by DIer which returns error of creating services, like *DI
+type diGetter interface {
+	GetE(name string) (interface{}, error)
+}
+
+// unregisterer is implemented by service registry, like micro/registry.Registry
+type unregisterer interface {
+	UnRegister()
+}
+
+// errorCloser is closed with the error of scope, like SQLConnTransaction
+type errorCloser interface {
+	CloseWithError(err error) error
+}
+
+// DI provlider a dependency injection service for nice, services could be
+// registered by name, or provided by constructor and resolved by type
 type DI struct {
-	store map[string]interface{}
-	mutex sync.RWMutex
+	store      map[string]interface{}
+	names      []string // registration order
+	named      map[string]*provider
+	providers  map[reflect.Type]*provider
+	singletons instances
+	mutex      sync.RWMutex
 }
 
 // NewDI create a DI instance
@@ -27,14 +57,576 @@ func NewDI() DIer {
 // nice dependency injection must be the special interface
 func (d *DI) Set(name string, v interface{}) {
 	d.mutex.Lock()
+	if _, ok := d.store[name]; !ok {
+		if _, ok = d.named[name]; !ok {
+			d.names = append(d.names, name)
+		}
+	}
 	d.store[name] = v
+	delete(d.named, name)
 	d.mutex.Unlock()
 }
 
 // Get fetch a di by name, return nil when name not set.
+// the service provided by ProvideName is created, nil is returned if creating
+// failed, see GetE for the error.
 func (d *DI) Get(name string) interface{} {
+	v, _ := d.GetE(name)
+	return v
+}
+
+// GetE fetch a di by name, return nil when name not set,
+// returns error if creating the service provided by ProvideName failed.
+func (d *DI) GetE(name string) (interface{}, error) {
 	d.mutex.RLock()
-	v := d.store[name]
+	v, p := d.store[name], d.named[name]
 	d.mutex.RUnlock()
-	return v
+	if p != nil {
+		value, err := d.resolveProvider(p, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return value.Interface(), nil
+	}
+	return v, nil
+}
+
+// Close closes singletons created by providers in reverse creation order,
+// then services registered by Set in reverse registration order,
+// services implement io.Closer will be closed, service registry will be unregistered.
+func (d *DI) Close() error {
+	errs := d.singletons.close(nil)
+
+	d.mutex.RLock()
+	names := make([]string, 0, len(d.names))
+	values := make([]interface{}, 0, len(d.names))
+	for _, name := range d.names {
+		if v, ok := d.store[name]; ok {
+			names, values = append(names, name), append(values, v)
+		}
+	}
+	d.mutex.RUnlock()
+
+	for i := len(values) - 1; i >= 0; i-- {
+		if err := closeInstance(values[i], nil); err != nil {
+			errs = append(errs, names[i]+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Lifetime is lifetime of instances created by provider
+type Lifetime int
+
+const (
+	// Singleton is created once when it is resolved first time, and shared
+	Singleton Lifetime = iota
+	// Transient is created every time it is resolved, it is closed by the scope
+	// if resolved in a scope, otherwise the caller owns and closes it
+	Transient
+	// Scoped is created once in each scope, like a request
+	Scoped
+)
+
+// String returns name of the lifetime
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	}
+	return "unknown"
+}
+
+// ErrDIUnsupported is returned when the DIer of application does not support typed injection
+var ErrDIUnsupported = errors.New("di: DIer does not support typed injection")
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// provider creates instances by constructor
+type provider struct {
+	name     string
+	ctor     reflect.Value
+	params   []reflect.Type
+	out      reflect.Type
+	lifetime Lifetime
+}
+
+// String returns type and name of the provider for errors
+func (p *provider) String() string {
+	if p.name != "" {
+		return p.out.String() + "(" + p.name + ")"
+	}
+	return p.out.String()
+}
+
+// instance is the cached instance of provider
+type instance struct {
+	mutex sync.Mutex
+	done  bool
+	value reflect.Value
+}
+
+// instances caches instances, and records them in order of creation for closing
+type instances struct {
+	mutex   sync.Mutex
+	store   map[*provider]*instance
+	created []interface{}
+}
+
+// get returns the cached instance of provider, created if not exists
+func (s *instances) get(p *provider) *instance {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.store == nil {
+		s.store = make(map[*provider]*instance)
+	}
+	i := s.store[p]
+	if i == nil {
+		i = new(instance)
+		s.store[p] = i
+	}
+	return i
+}
+
+// add records created instance
+func (s *instances) add(v interface{}) {
+	s.mutex.Lock()
+	s.created = append(s.created, v)
+	s.mutex.Unlock()
+}
+
+// close closes created instances in reverse order of creation
+func (s *instances) close(err error) []string {
+	s.mutex.Lock()
+	created := s.created
+	s.created = nil
+	s.store = nil
+	s.mutex.Unlock()
+
+	var errs []string
+	for i := len(created) - 1; i >= 0; i-- {
+		if e := closeInstance(created[i], err); e != nil {
+			errs = append(errs, fmt.Sprintf("%T: %v", created[i], e))
+		}
+	}
+	return errs
+}
+
+// closeInstance closes io.Closer, and unregisters service registry,
+// err is passed to the instance which closes with error
+func closeInstance(v interface{}, err error) error {
+	switch v := v.(type) {
+	case errorCloser:
+		return v.CloseWithError(err)
+	case io.Closer:
+		return v.Close()
+	case unregisterer:
+		v.UnRegister()
+	}
+	return nil
+}
+
+// Provide registers constructor of the type of its first return value, params
+// of constructor are resolved by type, the second return value could be error.
+// as are nil pointers of interfaces which are also provided, like (*nice.Db)(nil).
+// the later provider of the same type overrides the former.
+//
+// Example:
+//	d.Provide(func(conf *Config) (*Mysql, error) {
+//		return OpenMysql(conf.Get("mysql"))
+//	}, nice.Singleton, (*nice.Db)(nil))
+func (d *DI) Provide(constructor interface{}, lifetime Lifetime, as ...interface{}) error {
+	p, err := newProvider("", constructor, lifetime)
+	if err != nil {
+		return err
+	}
+	types := []reflect.Type{p.out}
+	for _, a := range as {
+		t := reflect.TypeOf(a)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			return fmt.Errorf("di: %T is not a pointer of interface", a)
+		}
+		if !p.out.Implements(t.Elem()) {
+			return fmt.Errorf("di: %s does not implement %s", p.out, t.Elem())
+		}
+		types = append(types, t.Elem())
+	}
+
+	d.mutex.Lock()
+	if d.providers == nil {
+		d.providers = make(map[reflect.Type]*provider)
+	}
+	for _, t := range types {
+		d.providers[t] = p
+	}
+	d.mutex.Unlock()
+	return nil
+}
+
+// ProvideName registers constructor of the named service, it is created lazily
+// by Get(name), and could be resolved by type only if it is not provided by Provide.
+// Get returns nil if the constructor returns error, see GetE.
+func (d *DI) ProvideName(name string, constructor interface{}, lifetime Lifetime) error {
+	if lifetime == Scoped {
+		return errors.New("di: named service can not be scoped")
+	}
+	p, err := newProvider(name, constructor, lifetime)
+	if err != nil {
+		return err
+	}
+	d.mutex.Lock()
+	if d.named == nil {
+		d.named = make(map[string]*provider)
+	}
+	if _, ok := d.named[name]; !ok {
+		if _, ok = d.store[name]; !ok {
+			d.names = append(d.names, name)
+		}
+	}
+	d.named[name] = p
+	delete(d.store, name)
+	d.mutex.Unlock()
+	return nil
+}
+
+// newProvider checks constructor and create provider
+func newProvider(name string, constructor interface{}, lifetime Lifetime) (*provider, error) {
+	v := reflect.ValueOf(constructor)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("di: constructor must be a func, got %T", constructor)
+	}
+	t := v.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf("di: constructor %s must return a value and an optional error", t)
+	}
+	if t.IsVariadic() {
+		return nil, fmt.Errorf("di: constructor %s can not be variadic", t)
+	}
+	if lifetime < Singleton || lifetime > Scoped {
+		return nil, fmt.Errorf("di: lifetime %d is invalid", lifetime)
+	}
+	p := &provider{name: name, ctor: v, out: t.Out(0), lifetime: lifetime}
+	for i := 0; i < t.NumIn(); i++ {
+		p.params = append(p.params, t.In(i))
+	}
+	return p, nil
+}
+
+// Resolve sets the value ptr points to with instance of its type.
+//
+// Example:
+//	var db nice.Db
+//	err := d.Resolve(&db)
+func (d *DI) Resolve(ptr interface{}) error {
+	return d.resolveInto(ptr, nil)
+}
+
+// Invoke calls f with params resolved by type, returns the error returned by f
+func (d *DI) Invoke(f interface{}) error {
+	return d.invoke(f, nil)
+}
+
+// Check checks all providers can be resolved without dependency cycle
+// and missing dependency, instances are not created.
+func (d *DI) Check() error {
+	d.mutex.RLock()
+	var ps []*provider
+	for _, p := range d.providers {
+		ps = append(ps, p)
+	}
+	for _, p := range d.named {
+		ps = append(ps, p)
+	}
+	d.mutex.RUnlock()
+
+	checked := make(map[*provider]bool)
+	var check func(p *provider, stack []*provider) error
+	check = func(p *provider, stack []*provider) error {
+		if err := checkCycle(p, stack); err != nil {
+			return err
+		}
+		if checked[p] {
+			return nil
+		}
+		stack = append(stack, p)
+		for _, t := range p.params {
+			dep, ok := d.lookup(t)
+			if dep == nil {
+				if !ok {
+					return fmt.Errorf("di: %s required by %s is not provided", t, p)
+				}
+				continue
+			}
+			if p.lifetime == Singleton && dep.lifetime == Scoped {
+				return fmt.Errorf("di: singleton %s depends on scoped %s", p, dep)
+			}
+			if err := check(dep, stack); err != nil {
+				return err
+			}
+		}
+		checked[p] = true
+		return nil
+	}
+	for _, p := range ps {
+		if err := check(p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns provider of the type, ok is true if the type is
+// provided by a value set by name.
+func (d *DI) lookup(t reflect.Type) (*provider, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if p := d.providers[t]; p != nil {
+		return p, true
+	}
+	for i := len(d.names) - 1; i >= 0; i-- {
+		if p := d.named[d.names[i]]; p != nil && p.out == t {
+			return p, true
+		}
+		if v, ok := d.store[d.names[i]]; ok && v != nil && assignable(reflect.TypeOf(v), t) {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// namedValue returns value set by name which is assignable to the type, the later first
+func (d *DI) namedValue(t reflect.Type) (reflect.Value, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	for i := len(d.names) - 1; i >= 0; i-- {
+		if v, ok := d.store[d.names[i]]; ok && v != nil && assignable(reflect.TypeOf(v), t) {
+			return reflect.ValueOf(v), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// assignable reports whether value of type v could be used as type t
+func assignable(v, t reflect.Type) bool {
+	return v == t || (t.Kind() == reflect.Interface && v.Implements(t))
+}
+
+// checkCycle returns error if provider is in the stack
+func checkCycle(p *provider, stack []*provider) error {
+	for i := range stack {
+		if stack[i] == p {
+			var chain []string
+			for _, s := range stack[i:] {
+				chain = append(chain, s.String())
+			}
+			return fmt.Errorf("di: dependency cycle %s -> %s", strings.Join(chain, " -> "), p)
+		}
+	}
+	return nil
+}
+
+// resolveInto resolves the type ptr points to in scope
+func (d *DI) resolveInto(ptr interface{}, s *Scope) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("di: Resolve requires a non-nil pointer, got %T", ptr)
+	}
+	value, err := d.resolve(v.Type().Elem(), s, nil)
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(value)
+	return nil
+}
+
+// invoke calls f with params resolved in scope
+func (d *DI) invoke(f interface{}, s *Scope) error {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("di: Invoke requires a func, got %T", f)
+	}
+	t := v.Type()
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		arg, err := d.resolve(t.In(i), s, nil)
+		if err != nil {
+			return err
+		}
+		args[i] = arg
+	}
+	out := v.Call(args)
+	if len(out) > 0 && out[len(out)-1].Type() == errorType && !out[len(out)-1].IsNil() {
+		return out[len(out)-1].Interface().(error)
+	}
+	return nil
+}
+
+// resolve returns instance of the type
+func (d *DI) resolve(t reflect.Type, s *Scope, stack []*provider) (reflect.Value, error) {
+	if s != nil {
+		if v, ok := s.value(t); ok {
+			return v, nil
+		}
+	}
+	p, _ := d.lookup(t)
+	if p == nil {
+		if v, ok := d.namedValue(t); ok {
+			return v, nil
+		}
+		if len(stack) > 0 {
+			return reflect.Value{}, fmt.Errorf("di: %s required by %s is not provided", t, stack[len(stack)-1])
+		}
+		return reflect.Value{}, fmt.Errorf("di: %s is not provided", t)
+	}
+	return d.resolveProvider(p, s, stack)
+}
+
+// resolveProvider returns instance of provider by its lifetime
+func (d *DI) resolveProvider(p *provider, s *Scope, stack []*provider) (reflect.Value, error) {
+	if err := checkCycle(p, stack); err != nil {
+		return reflect.Value{}, err
+	}
+
+	var cache *instances
+	switch p.lifetime {
+	case Transient:
+		v, err := d.create(p, s, stack)
+		if err != nil {
+			return v, err
+		}
+		// transient created outside of scope is owned by the caller
+		if s != nil {
+			s.scoped.add(v.Interface())
+		}
+		return v, nil
+	case Scoped:
+		if s == nil {
+			if len(stack) > 0 {
+				return reflect.Value{}, fmt.Errorf("di: scoped %s required by %s is resolved outside of scope", p, stack[len(stack)-1])
+			}
+			return reflect.Value{}, fmt.Errorf("di: scoped %s is resolved outside of scope", p)
+		}
+		cache = &s.scoped
+	default:
+		// dependencies of singleton are resolved outside of scope
+		cache, s = &d.singletons, nil
+	}
+
+	i := cache.get(p)
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if !i.done {
+		v, err := d.create(p, s, stack)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		i.value, i.done = v, true
+		cache.add(v.Interface())
+	}
+	return i.value, nil
+}
+
+// create calls constructor of provider with resolved params
+func (d *DI) create(p *provider, s *Scope, stack []*provider) (reflect.Value, error) {
+	stack = append(stack, p)
+	args := make([]reflect.Value, len(p.params))
+	for i, t := range p.params {
+		arg, err := d.resolve(t, s, stack)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		args[i] = arg
+	}
+	out := p.ctor.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("di: create %s failed: %v", p, out[1].Interface())
+	}
+	return out[0], nil
+}
+
+// Scope is a scope of DI, like a request, scoped instances are created once
+// in the scope, they and transient instances created in the scope are
+// closed in reverse order by Close. values could be set into the scope by Set.
+type Scope struct {
+	di     *DI
+	scoped instances
+	mutex  sync.RWMutex
+	values map[reflect.Type]reflect.Value
+}
+
+// NewScope create a scope
+func (d *DI) NewScope() *Scope {
+	return &Scope{di: d}
+}
+
+// Resolve sets the value ptr points to with instance of its type in the scope
+func (s *Scope) Resolve(ptr interface{}) error {
+	return s.di.resolveInto(ptr, s)
+}
+
+// Invoke calls f with params resolved in the scope
+func (s *Scope) Invoke(f interface{}) error {
+	return s.di.invoke(f, s)
+}
+
+// Set sets value into the scope, it is resolved by its type and the interfaces
+// of as, which are nil pointers of interfaces. the value is not closed by the scope.
+//
+// Example:
+//	c.Scope().Set(user, (*Member)(nil))
+func (s *Scope) Set(v interface{}, as ...interface{}) error {
+	if v == nil {
+		return errors.New("di: value set into scope is nil")
+	}
+	value := reflect.ValueOf(v)
+	types := []reflect.Type{value.Type()}
+	for _, a := range as {
+		t := reflect.TypeOf(a)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			return fmt.Errorf("di: %T is not a pointer of interface", a)
+		}
+		if !value.Type().Implements(t.Elem()) {
+			return fmt.Errorf("di: %s does not implement %s", value.Type(), t.Elem())
+		}
+		types = append(types, t.Elem())
+	}
+	s.mutex.Lock()
+	if s.values == nil {
+		s.values = make(map[reflect.Type]reflect.Value)
+	}
+	for _, t := range types {
+		s.values[t] = value
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+// value returns value set into the scope
+func (s *Scope) value(t reflect.Type) (reflect.Value, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	v, ok := s.values[t]
+	return v, ok
+}
+
+// Close closes instances created in the scope in reverse order
+func (s *Scope) Close() error {
+	return s.CloseWithError(nil)
+}
+
+// CloseWithError closes instances created in the scope in reverse order,
+// err is passed to instances implement CloseWithError(error) error, like
+// SQLConnTransaction, which is committed if err is nil, otherwise rolled back.
+func (s *Scope) CloseWithError(err error) error {
+	if errs := s.scoped.close(err); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
 }
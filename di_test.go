@@ -1,10 +1,13 @@
 package nice
 
 import (
-	. "github.com/smartystreets/goconvey/convey"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestDISetLogger1(t *testing.T) {
@@ -66,3 +69,222 @@ func TestDIGet1(t *testing.T) {
 		So(v.(string), ShouldEqual, "hiDI")
 	})
 }
+
+type closeService struct {
+	name string
+	seq  *[]string
+}
+
+func (s *closeService) Close() error {
+	*s.seq = append(*s.seq, s.name)
+	return nil
+}
+
+func TestDIClose1(t *testing.T) {
+	Convey("close registered di in reverse order", t, func() {
+		var seq []string
+		d := NewDI().(*DI)
+		d.Set("db", &closeService{"db", &seq})
+		d.Set("name", "nice")
+		d.Set("cache", &closeService{"cache", &seq})
+		d.Set("db", &closeService{"db", &seq})
+		So(d.Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"cache", "db"})
+	})
+}
+
+type diConf struct{ dsn string }
+
+type diRepo struct {
+	conf *diConf
+	seq  *[]string
+}
+
+func (r *diRepo) Close() error {
+	*r.seq = append(*r.seq, "repo")
+	return nil
+}
+
+type diNamer interface {
+	Name() string
+}
+
+type diService struct {
+	repo *diRepo
+}
+
+func (s *diService) Name() string {
+	return "service:" + s.repo.conf.dsn
+}
+
+type diA struct{}
+type diB struct{}
+
+func TestDIProvide1(t *testing.T) {
+	Convey("typed di", t, func() {
+		var seq []string
+		confs := 0
+		d := NewDI().(*DI)
+		d.Set("seq", &seq)
+		So(d.Provide(func() *diConf {
+			confs++
+			return &diConf{dsn: "db"}
+		}, Singleton), ShouldBeNil)
+		So(d.Provide(func(c *diConf, seq *[]string) (*diRepo, error) {
+			return &diRepo{conf: c, seq: seq}, nil
+		}, Singleton), ShouldBeNil)
+		So(d.Provide(func(r *diRepo) *diService {
+			return &diService{repo: r}
+		}, Transient, (*diNamer)(nil)), ShouldBeNil)
+		So(d.Check(), ShouldBeNil)
+
+		var s1, s2 *diService
+		So(d.Resolve(&s1), ShouldBeNil)
+		So(d.Resolve(&s2), ShouldBeNil)
+		So(s1, ShouldNotPointTo, s2)
+		So(s1.repo, ShouldPointTo, s2.repo)
+		So(confs, ShouldEqual, 1)
+
+		var namer diNamer
+		So(d.Resolve(&namer), ShouldBeNil)
+		So(namer.Name(), ShouldEqual, "service:db")
+		So(d.Invoke(func(n diNamer, c *diConf) error {
+			So(n.Name(), ShouldEqual, "service:"+c.dsn)
+			return errors.New("invoked")
+		}).Error(), ShouldEqual, "invoked")
+
+		So(d.Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"repo"})
+
+		Convey("errors", func() {
+			So(d.Provide(nil, Singleton), ShouldNotBeNil)
+			So(d.Provide(func() {}, Singleton), ShouldNotBeNil)
+			So(d.Provide(func() (int, int) { return 0, 0 }, Singleton), ShouldNotBeNil)
+			So(d.Provide(func() int { return 0 }, Lifetime(9)), ShouldNotBeNil)
+			So(d.Provide(func() *diConf { return nil }, Singleton, (*diNamer)(nil)), ShouldNotBeNil)
+			So(d.Provide(func() *diConf { return nil }, Singleton, diNamer(nil)), ShouldNotBeNil)
+
+			var a *diA
+			So(d.Resolve(&a).Error(), ShouldEqual, "di: *nice.diA is not provided")
+			So(d.Resolve(a), ShouldNotBeNil)
+			So(d.Provide(func(b *diB) *diA { return &diA{} }, Singleton), ShouldBeNil)
+			So(d.Check(), ShouldNotBeNil)
+			So(d.Resolve(&a).Error(), ShouldEqual, "di: *nice.diB required by *nice.diA is not provided")
+
+			So(d.Provide(func() (*diB, error) { return nil, errors.New("failed") }, Singleton), ShouldBeNil)
+			So(d.Resolve(&a).Error(), ShouldEqual, "di: create *nice.diB failed: failed")
+		})
+		Convey("cycle", func() {
+			So(d.Provide(func(b *diB) *diA { return &diA{} }, Singleton), ShouldBeNil)
+			So(d.Provide(func(a *diA) *diB { return &diB{} }, Transient), ShouldBeNil)
+			So(d.Check().Error(), ShouldContainSubstring, "di: dependency cycle")
+			var a *diA
+			So(d.Resolve(&a).Error(), ShouldEqual, "di: dependency cycle *nice.diA -> *nice.diB -> *nice.diA")
+		})
+	})
+}
+
+func TestDIScope1(t *testing.T) {
+	Convey("scoped di", t, func() {
+		var seq []string
+		d := NewDI().(*DI)
+		d.Set("seq", &seq)
+		d.Provide(func() *diConf { return &diConf{dsn: "db"} }, Singleton)
+		d.Provide(func(c *diConf, seq *[]string) *diRepo {
+			*seq = append(*seq, "new")
+			return &diRepo{conf: c, seq: seq}
+		}, Scoped)
+		d.Provide(func(r *diRepo) *diService { return &diService{repo: r} }, Transient)
+
+		var r *diRepo
+		So(d.Resolve(&r).Error(), ShouldEqual, "di: scoped *nice.diRepo is resolved outside of scope")
+
+		s := d.NewScope()
+		var s1, s2 *diService
+		So(s.Resolve(&s1), ShouldBeNil)
+		So(s.Invoke(func(s *diService) { s2 = s }), ShouldBeNil)
+		So(s1.repo, ShouldPointTo, s2.repo)
+
+		var s3 *diService
+		s4 := d.NewScope()
+		So(s4.Resolve(&s3), ShouldBeNil)
+		So(s3.repo, ShouldNotPointTo, s1.repo)
+		So(seq, ShouldResemble, []string{"new", "new"})
+
+		So(s.Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"new", "new", "repo"})
+
+		d.Provide(func(r *diRepo) *diA { return &diA{} }, Singleton)
+		So(d.Check().Error(), ShouldEqual, "di: singleton *nice.diA depends on scoped *nice.diRepo")
+		var a *diA
+		So(s4.Resolve(&a).Error(), ShouldEqual, "di: scoped *nice.diRepo required by *nice.diA is resolved outside of scope")
+	})
+}
+
+func TestDIScope2(t *testing.T) {
+	Convey("transient closers in and outside of scope", t, func() {
+		var seq []string
+		n := 0
+		d := NewDI().(*DI)
+		d.Provide(func() *closeService {
+			n++
+			return &closeService{fmt.Sprintf("t%d", n), &seq}
+		}, Transient)
+		d.ProvideName("named", func(c *closeService) *diConf { return &diConf{} }, Transient)
+		d.Provide(func() *diConf { return &diConf{} }, Transient)
+
+		var c *closeService
+		So(d.Resolve(&c), ShouldBeNil)
+		So(d.Invoke(func(c *closeService) {}), ShouldBeNil)
+		So(d.Get("named"), ShouldNotBeNil)
+		var conf *diConf
+		So(d.Resolve(&conf), ShouldBeNil)
+
+		s := d.NewScope()
+		So(s.Resolve(&c), ShouldBeNil)
+		So(s.Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"t4"})
+
+		// owned by the caller outside of scope
+		So(d.singletons.created, ShouldBeEmpty)
+		So(d.Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"t4"})
+	})
+}
+
+func TestDIProvideName1(t *testing.T) {
+	Convey("named provider", t, func() {
+		var seq []string
+		created := 0
+		b2 := New()
+		So(b2.Db(), ShouldBeNil)
+		So(b2.Cache(), ShouldBeNil)
+
+		So(b2.ProvideName("repo", func(c *diConf) *diRepo {
+			created++
+			return &diRepo{conf: c, seq: &seq}
+		}, Singleton), ShouldBeNil)
+		So(b2.ProvideName("scoped", func() *diA { return nil }, Scoped), ShouldNotBeNil)
+		So(b2.GetDI("repo"), ShouldBeNil)
+		_, err := b2.GetDIE("repo")
+		So(err, ShouldNotBeNil)
+
+		So(b2.Provide(func() *diConf { return &diConf{dsn: "db"} }, Singleton), ShouldBeNil)
+		b2.SetDI("closer", &closeService{"closer", &seq})
+		So(created, ShouldEqual, 0)
+		So(b2.GetDI("repo").(*diRepo).conf.dsn, ShouldEqual, "db")
+		So(b2.GetDI("repo"), ShouldEqual, b2.GetDI("repo"))
+		So(created, ShouldEqual, 1)
+
+		var r *diRepo
+		So(b2.Resolve(&r), ShouldBeNil)
+		So(r, ShouldPointTo, b2.GetDI("repo"))
+		var l Logger
+		So(b2.Resolve(&l), ShouldBeNil)
+		So(l, ShouldEqual, b2.Logger())
+		So(b2.Invoke(func(r *diRepo, l Logger) {}), ShouldBeNil)
+
+		So(b2.di.(*DI).Close(), ShouldBeNil)
+		So(seq, ShouldResemble, []string{"repo", "closer"})
+	})
+}
@@ -0,0 +1,156 @@
+package nice
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTPError represents an error with HTTP status code,
+// handlers can pass it to c.Error or panic with it under the Recovery middleware.
+type HTTPError struct {
+	XMLName  xml.Name    `json:"-" xml:"error"`
+	Code     int         `json:"code" xml:"code"`
+	Message  string      `json:"message" xml:"message"`
+	Internal error       `json:"-" xml:"-"`
+	Details  interface{} `json:"details,omitempty" xml:"details,omitempty"`
+	// RequestID is set by the default error handler when the request has an id
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty"`
+}
+
+// NewHTTPError create a HTTPError, message default is the status text of code
+func NewHTTPError(code int, message ...string) *HTTPError {
+	e := &HTTPError{Code: code, Message: http.StatusText(code)}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+	return e
+}
+
+// Error implements error interface
+func (e *HTTPError) Error() string {
+	if e.Internal == nil {
+		return fmt.Sprintf("code=%d, message=%s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("code=%d, message=%s, internal=%v", e.Code, e.Message, e.Internal)
+}
+
+// WithInternal set the internal error which is logged but not responded
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	e.Internal = err
+	return e
+}
+
+// WithDetails set details responded with the error, like field errors
+func (e *HTTPError) WithDetails(v interface{}) *HTTPError {
+	e.Details = v
+	return e
+}
+
+// toHTTPError converts error to HTTPError, wrapped errors are unwrapped,
+// ParamError and ValidationErrors are 400, others and HTTPError without code are 500.
+func toHTTPError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		if he.Code == 0 {
+			e := *he
+			e.Code = http.StatusInternalServerError
+			if e.Message == "" {
+				e.Message = http.StatusText(e.Code)
+			}
+			return &e
+		}
+		return he
+	}
+	var pe *ParamError
+	if errors.As(err, &pe) {
+		return NewHTTPError(http.StatusBadRequest, pe.Error()).WithInternal(err)
+	}
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		return NewHTTPError(http.StatusBadRequest).WithInternal(err).WithDetails(ve)
+	}
+	return NewHTTPError(http.StatusInternalServerError).WithInternal(err)
+}
+
+// DefaultErrorHandler responds the error with status code of HTTPError,
+// the body format is negotiated by Accept header: JSON, XML, HTML or plain text.
+// Message of 5xx errors is hidden when not in debug mode.
+// The request id is logged and responded, so clients can report it.
+func (n *Nice) DefaultErrorHandler(err error, c *Context) {
+	id := c.RequestID()
+	if id != "" {
+		n.Logger().Println("request_id="+id, err)
+	} else {
+		n.Logger().Println(err)
+	}
+
+	he := toHTTPError(err)
+	resp := *he
+	resp.RequestID = id
+	if resp.Code >= http.StatusInternalServerError {
+		if n.debug {
+			resp.Message = err.Error()
+		} else {
+			resp.Message = http.StatusText(resp.Code)
+			resp.Details = nil
+		}
+	}
+
+	// response has been written, only log it
+	if c.Resp.Wrote() {
+		return
+	}
+
+	switch negotiate(c.Req.Header.Get("Accept"), TextPlain, ApplicationJSON, ApplicationXML, TextHTML) {
+	case ApplicationJSON:
+		c.JSON(resp.Code, &resp)
+	case ApplicationXML:
+		c.XML(resp.Code, &resp)
+	case TextHTML:
+		title := strconv.Itoa(resp.Code) + " " + http.StatusText(resp.Code)
+		body := "<html><head><title>" + title + "</title></head><body><h1>" + title +
+			"</h1><p>" + template.HTMLEscapeString(resp.Message) + "</p>"
+		if id != "" {
+			body += "<p>Request ID: " + template.HTMLEscapeString(id) + "</p>"
+		}
+		c.Text(resp.Code, []byte(body+"</body></html>"))
+	default:
+		http.Error(c.Resp, resp.Message, resp.Code)
+	}
+}
+
+// negotiate returns the offer which has the highest quality in accept header,
+// returns empty when none is acceptable or accept is empty.
+func negotiate(accept string, offers ...string) string {
+	best, bestQ := "", 0.0
+	for _, spec := range strings.Split(accept, ",") {
+		parts := strings.Split(spec, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, _ = strconv.ParseFloat(p[2:], 64)
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, offer := range offers {
+			if mediaType == offer || mediaType == "*/*" ||
+				(strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, mediaType[:len(mediaType)-1])) {
+				best, bestQ = offer, q
+				break
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,128 @@
+package nice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func errorRequest(b *Nice, uri, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", uri, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, req)
+	return w
+}
+
+func TestHTTPError1(t *testing.T) {
+	Convey("http error", t, func() {
+		e := NewHTTPError(http.StatusNotFound)
+		So(e.Message, ShouldEqual, "Not Found")
+		So(e.Error(), ShouldEqual, "code=404, message=Not Found")
+		e = NewHTTPError(http.StatusConflict, "user exists").WithInternal(fmt.Errorf("duplicate key"))
+		So(e.Error(), ShouldEqual, "code=409, message=user exists, internal=duplicate key")
+
+		So(toHTTPError(fmt.Errorf("BOMB")).Code, ShouldEqual, http.StatusInternalServerError)
+		So(toHTTPError(&ParamError{Source: SourceQuery, Name: "id", Err: ErrValueMissing}).Code, ShouldEqual, http.StatusBadRequest)
+		So(toHTTPError(ValidationErrors{}).Code, ShouldEqual, http.StatusBadRequest)
+
+		wrapped := fmt.Errorf("create user: %w", NewHTTPError(http.StatusConflict))
+		So(toHTTPError(wrapped).Code, ShouldEqual, http.StatusConflict)
+		pe := &ParamError{Source: SourceQuery, Name: "id", Err: ErrValueMissing}
+		So(toHTTPError(fmt.Errorf("bind: %w", pe)).Code, ShouldEqual, http.StatusBadRequest)
+		So(toHTTPError(fmt.Errorf("bind: %w", ValidationErrors{})).Code, ShouldEqual, http.StatusBadRequest)
+
+		e = toHTTPError(&HTTPError{})
+		So(e.Code, ShouldEqual, http.StatusInternalServerError)
+		So(e.Message, ShouldEqual, "Internal Server Error")
+	})
+}
+
+func TestHTTPError2(t *testing.T) {
+	Convey("default error handler", t, func() {
+		b2 := New()
+		b2.SetDebug(false)
+		b2.Get("/403", func(c *Context) {
+			c.Error(NewHTTPError(http.StatusForbidden, "no permission"))
+		})
+		b2.Get("/500", func(c *Context) {
+			c.Error(fmt.Errorf("BOMB"))
+		})
+		b2.Get("/422", func(c *Context) {
+			c.Error(NewHTTPError(http.StatusUnprocessableEntity).WithDetails(ValidationErrors{{Field: "name", Rule: "required", Message: "is required"}}))
+		})
+		b2.Get("/zero", func(c *Context) {
+			c.Error(&HTTPError{Message: "no code"})
+		})
+		b2.Get("/wrote", func(c *Context) {
+			c.String(200, "ok")
+			c.Error(NewHTTPError(http.StatusBadRequest))
+		})
+
+		Convey("zero code", func() {
+			w := errorRequest(b2, "/zero", "")
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		})
+		Convey("plain text", func() {
+			w := errorRequest(b2, "/403", "")
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(w.Body.String(), ShouldEqual, "no permission\n")
+			w = errorRequest(b2, "/500", "*/*")
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Body.String(), ShouldEqual, "Internal Server Error\n")
+		})
+		Convey("json", func() {
+			w := errorRequest(b2, "/422", "application/json")
+			So(w.Code, ShouldEqual, http.StatusUnprocessableEntity)
+			So(w.Header().Get("Content-Type"), ShouldEqual, ApplicationJSONCharsetUTF8)
+			var v map[string]interface{}
+			So(json.Unmarshal(w.Body.Bytes(), &v), ShouldBeNil)
+			So(v["code"], ShouldEqual, 422)
+			So(v["details"], ShouldHaveLength, 1)
+		})
+		Convey("xml", func() {
+			w := errorRequest(b2, "/403", "application/xml;q=0.9, text/plain;q=0.1")
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(w.Body.String(), ShouldContainSubstring, "<error><code>403</code><message>no permission</message></error>")
+		})
+		Convey("html", func() {
+			w := errorRequest(b2, "/403", "text/html,application/xhtml+xml,*/*;q=0.8")
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(strings.Contains(w.Body.String(), "<h1>403 Forbidden</h1>"), ShouldBeTrue)
+		})
+		Convey("request id", func() {
+			b2.Get("/rid", func(c *Context) {
+				c.SetRequestID("rid-1")
+				c.Error(NewHTTPError(http.StatusForbidden))
+			})
+			w := errorRequest(b2, "/rid", "application/json")
+			So(w.Header().Get(HeaderXRequestID), ShouldEqual, "rid-1")
+			var v map[string]interface{}
+			So(json.Unmarshal(w.Body.Bytes(), &v), ShouldBeNil)
+			So(v["request_id"], ShouldEqual, "rid-1")
+			w = errorRequest(b2, "/rid", "text/html")
+			So(w.Body.String(), ShouldContainSubstring, "<p>Request ID: rid-1</p>")
+		})
+		Convey("response wrote", func() {
+			w := errorRequest(b2, "/wrote", "")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, "ok")
+		})
+	})
+}
+
+func TestNegotiate1(t *testing.T) {
+	Convey("negotiate accept", t, func() {
+		So(negotiate("", TextPlain, ApplicationJSON), ShouldEqual, "")
+		So(negotiate("application/json", TextPlain, ApplicationJSON), ShouldEqual, ApplicationJSON)
+		So(negotiate("text/*", ApplicationJSON, TextHTML), ShouldEqual, TextHTML)
+		So(negotiate("image/png", TextPlain, ApplicationJSON), ShouldEqual, "")
+	})
+}
@@ -0,0 +1,120 @@
+package nice
+
+import (
+	"strings"
+)
+
+// host is a route table for requests of the host, like api.example.com,
+// the label begins with colon is a param, like :tenant.example.com
+type host struct {
+	pattern  string
+	labels   []string
+	wildcard bool
+	router   Router
+	notFound HandlerFunc
+}
+
+// newHost create a host route table, settings of the default router are inherited
+func newHost(pattern string, n *Nice) *host {
+	h := &host{pattern: strings.ToLower(pattern)}
+	h.labels = strings.Split(h.pattern, ".")
+	for _, label := range h.labels {
+		if label == "" || label == ":" {
+			panic("nice.Host pattern [" + pattern + "] is invalid")
+		}
+		if label[0] == ':' {
+			h.wildcard = true
+		}
+	}
+	h.router = n.newRouter()
+	return h
+}
+
+// match checks the request host, and set params of host into context
+func (h *host) match(hostname string, c *Context) bool {
+	labels := strings.Split(hostname, ".")
+	if len(labels) != len(h.labels) {
+		return false
+	}
+	for i := range labels {
+		if h.labels[i][0] != ':' && h.labels[i] != labels[i] {
+			return false
+		}
+	}
+	for i := range labels {
+		if h.labels[i][0] == ':' {
+			c.SetParam(h.labels[i][1:], labels[i])
+		}
+	}
+	return true
+}
+
+// Host registers routes only for requests of the host, routes registered
+// in f are added to the host route table, also not found handler set in f.
+// requests of the host not fall back to the default route table.
+//
+// Example:
+//	nice.Host("admin.example.com", func() {
+//		nice.Get("/", h)
+//	})
+//	nice.Host(":tenant.example.com", func() {
+//		nice.Get("/", func(c *nice.Context) {
+//			c.String(200, c.Param("tenant"))
+//		})
+//	})
+func (n *Nice) Host(pattern string, f func()) {
+	if n.host != nil {
+		panic("nice.Host can not be nested")
+	}
+	h := n.findHost(pattern)
+	if h == nil {
+		h = newHost(pattern, n)
+		// exact hosts are matched before wildcard hosts
+		pos := len(n.hosts)
+		if !h.wildcard {
+			for pos = 0; pos < len(n.hosts) && !n.hosts[pos].wildcard; pos++ {
+			}
+		}
+		n.hosts = append(n.hosts, nil)
+		copy(n.hosts[pos+1:], n.hosts[pos:])
+		n.hosts[pos] = h
+	}
+
+	// restore the router building by SwapRoutes
+	prev := n.building.Load()
+	if prev == nil {
+		prev = routerBox{}
+	}
+	n.building.Store(routerBox{h.router})
+	n.host = h
+	defer func() {
+		n.building.Store(prev)
+		n.host = nil
+	}()
+	f()
+}
+
+// findHost returns registered host by pattern
+func (n *Nice) findHost(pattern string) *host {
+	pattern = strings.ToLower(pattern)
+	for _, h := range n.hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+	return nil
+}
+
+// matchHost returns the host which matches the request, nil if none matches
+func (n *Nice) matchHost(c *Context) *host {
+	hostname := strings.ToLower(c.Req.Host)
+	if i := strings.LastIndexByte(hostname, ':'); i > strings.LastIndexByte(hostname, ']') {
+		hostname = hostname[:i]
+	}
+	for _, h := range n.hosts {
+		if h.match(hostname, c) {
+			return h
+		}
+	}
+	return nil
+}
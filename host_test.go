@@ -0,0 +1,90 @@
+package nice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func hostRequest(b *Nice, host, uri string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", uri, nil)
+	req.Host = host
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, req)
+	return w
+}
+
+func TestHost1(t *testing.T) {
+	Convey("host routing", t, func() {
+		b2 := New()
+		b2.Get("/", func(c *Context) {
+			c.String(200, "default")
+		})
+		b2.Host(":tenant.example.com", func() {
+			b2.Get("/", func(c *Context) {
+				c.String(200, "tenant:"+c.Param("tenant"))
+			})
+			b2.Get("/users/:id", func(c *Context) {
+				c.String(200, c.Param("tenant")+":"+c.Param("id"))
+			}).Name("tenant_user")
+		})
+		b2.Host("admin.example.com", func() {
+			b2.Get("/", func(c *Context) {
+				c.String(200, "admin")
+			})
+			b2.SetNotFound(func(c *Context) {
+				c.String(404, "admin not found")
+			})
+		})
+
+		w := hostRequest(b2, "admin.example.com:8080", "/")
+		So(w.Body.String(), ShouldEqual, "admin")
+		w = hostRequest(b2, "Shop.example.com", "/")
+		So(w.Body.String(), ShouldEqual, "tenant:shop")
+		w = hostRequest(b2, "shop.example.com", "/users/1")
+		So(w.Body.String(), ShouldEqual, "shop:1")
+		w = hostRequest(b2, "example.com", "/")
+		So(w.Body.String(), ShouldEqual, "default")
+		w = hostRequest(b2, "a.b.example.com", "/")
+		So(w.Body.String(), ShouldEqual, "default")
+
+		w = hostRequest(b2, "admin.example.com", "/none")
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldEqual, "admin not found")
+		w = hostRequest(b2, "shop.example.com", "/none")
+		So(w.Body.String(), ShouldEqual, "Not Found\n")
+
+		So(b2.URLFor("tenant_user", 2), ShouldEqual, "/users/2")
+		So(func() { b2.Host("a.com", func() { b2.Host("b.com", func() {}) }) }, ShouldPanic)
+		So(func() { b2.Host("a..com", func() {}) }, ShouldPanic)
+	})
+}
+
+func TestHost2(t *testing.T) {
+	Convey("host in SwapRoutes", t, func() {
+		b2 := New()
+		b2.Get("/old", func(c *Context) {
+			c.String(200, "old")
+		})
+		b2.SwapRoutes(func() {
+			b2.Get("/before", func(c *Context) {
+				c.String(200, "before")
+			})
+			b2.Host("admin.example.com", func() {
+				b2.Get("/", func(c *Context) {
+					c.String(200, "admin")
+				})
+			})
+			b2.Get("/after", func(c *Context) {
+				c.String(200, "after")
+			})
+		})
+
+		So(hostRequest(b2, "example.com", "/before").Body.String(), ShouldEqual, "before")
+		So(hostRequest(b2, "example.com", "/after").Body.String(), ShouldEqual, "after")
+		So(hostRequest(b2, "example.com", "/old").Code, ShouldEqual, http.StatusNotFound)
+		So(hostRequest(b2, "admin.example.com", "/").Body.String(), ShouldEqual, "admin")
+	})
+}
@@ -1,5 +1,19 @@
 package nice
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // Logger provlider a basic log interface for nice
 type Logger interface {
 	Print(v ...interface{})
@@ -12,3 +26,357 @@ type Logger interface {
 	Panicf(format string, v ...interface{})
 	Panicln(v ...interface{})
 }
+
+// LevelLogger is a leveled structured logger, kv are key-value pairs of fields
+type LevelLogger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+	// With returns a logger with the fields added to every entry
+	With(kv ...interface{}) LevelLogger
+}
+
+// Level is level of log
+type Level int32
+
+const (
+	// DebugLevel logs are for development
+	DebugLevel Level = iota - 1
+	// InfoLevel is the default level
+	InfoLevel
+	// WarnLevel logs are more important than info
+	WarnLevel
+	// ErrorLevel logs are errors should be handled
+	ErrorLevel
+)
+
+// String returns lower case name of the level
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return "level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ParseLevel parses level from name, like debug, info, warn, error
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return DebugLevel, nil
+	case "info", "":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, errors.New("unknown log level " + name)
+}
+
+// Entry is a log entry
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []interface{} // key-value pairs
+}
+
+// Encoder encodes log entry into buf
+type Encoder interface {
+	Encode(buf *bytes.Buffer, e *Entry)
+}
+
+// JSONEncoder encodes entry as a json line, like
+// {"time":"2006-01-02T15:04:05.000Z07:00","level":"info","msg":"hello","key":"value"}
+type JSONEncoder struct{}
+
+// Encode implements Encoder
+func (JSONEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString(`{"time":"`)
+	buf.WriteString(e.Time.Format("2006-01-02T15:04:05.000Z07:00"))
+	buf.WriteString(`","level":"`)
+	buf.WriteString(e.Level.String())
+	buf.WriteString(`","msg":`)
+	writeJSON(buf, e.Message)
+	rangeFields(e.Fields, func(key string, value interface{}) {
+		buf.WriteByte(',')
+		writeJSON(buf, key)
+		buf.WriteByte(':')
+		writeJSON(buf, fieldValue(value))
+	})
+	buf.WriteString("}\n")
+}
+
+// writeJSON writes value as json, string of value if it can not be marshaled
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
+
+// ConsoleEncoder encodes entry as a human readable line, like
+// 2006-01-02 15:04:05.000 INFO hello key=value
+type ConsoleEncoder struct {
+	// NoTime omits time, for writers which add time, like log.Logger
+	NoTime bool
+}
+
+// Encode implements Encoder
+func (ce ConsoleEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	if !ce.NoTime {
+		buf.WriteString(e.Time.Format("2006-01-02 15:04:05.000"))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(strings.ToUpper(e.Level.String()))
+	buf.WriteByte(' ')
+	buf.WriteString(quoteControl(e.Message))
+	rangeFields(e.Fields, func(key string, value interface{}) {
+		buf.WriteByte(' ')
+		buf.WriteString(quoteControl(key))
+		buf.WriteByte('=')
+		s := fmt.Sprint(fieldValue(value))
+		if s == "" || strings.ContainsAny(s, " \"=") {
+			s = strconv.Quote(s)
+		} else {
+			s = quoteControl(s)
+		}
+		buf.WriteString(s)
+	})
+	buf.WriteByte('\n')
+}
+
+// quoteControl quotes s if it contains control characters,
+// so that a value with newline can not forge another line.
+func quoteControl(s string) string {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// rangeFields calls f for each key-value pair, the value without key is named by !BADKEY
+func rangeFields(kv []interface{}, f func(key string, value interface{})) {
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			f("!BADKEY", kv[i])
+			return
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		f(key, kv[i+1])
+	}
+}
+
+// fieldValue converts error and fmt.Stringer like time.Duration to string for encoding
+func fieldValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// Log is a leveled structured logger implements Logger and LevelLogger,
+// Print functions log at info level, Fatal and Panic functions log at error level.
+type Log struct {
+	out     io.Writer
+	mutex   *sync.Mutex
+	level   *int32
+	encoder Encoder
+	fields  []interface{}
+}
+
+// NewLog create a logger writes entries not lower than level to w by encoder
+//
+// Example:
+//	app.SetDI("logger", nice.NewLog(os.Stderr, nice.InfoLevel, nice.JSONEncoder{}))
+func NewLog(w io.Writer, level Level, encoder Encoder) *Log {
+	if encoder == nil {
+		encoder = ConsoleEncoder{}
+	}
+	l := int32(level)
+	return &Log{out: w, mutex: new(sync.Mutex), level: &l, encoder: encoder}
+}
+
+// Level returns the level of the logger
+func (l *Log) Level() Level {
+	return Level(atomic.LoadInt32(l.level))
+}
+
+// SetLevel changes level of the logger and loggers created by With,
+// it is safe to be called when logging, like when config changed.
+func (l *Log) SetLevel(level Level) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+// Enabled returns whether the level is logged
+func (l *Log) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// With returns a logger with the fields added to every entry
+func (l *Log) With(kv ...interface{}) LevelLogger {
+	nl := *l
+	nl.fields = make([]interface{}, 0, len(l.fields)+len(kv))
+	nl.fields = append(append(nl.fields, l.fields...), kv...)
+	return &nl
+}
+
+// log writes the entry
+func (l *Log) log(level Level, msg string, kv []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	e := &Entry{Time: time.Now(), Level: level, Message: msg, Fields: l.fields}
+	if len(kv) > 0 {
+		e.Fields = append(append(make([]interface{}, 0, len(l.fields)+len(kv)), l.fields...), kv...)
+	}
+	var buf bytes.Buffer
+	l.encoder.Encode(&buf, e)
+	l.mutex.Lock()
+	l.out.Write(buf.Bytes())
+	l.mutex.Unlock()
+}
+
+// Debug logs at debug level
+func (l *Log) Debug(msg string, kv ...interface{}) {
+	l.log(DebugLevel, msg, kv)
+}
+
+// Info logs at info level
+func (l *Log) Info(msg string, kv ...interface{}) {
+	l.log(InfoLevel, msg, kv)
+}
+
+// Warn logs at warn level
+func (l *Log) Warn(msg string, kv ...interface{}) {
+	l.log(WarnLevel, msg, kv)
+}
+
+// Error logs at error level
+func (l *Log) Error(msg string, kv ...interface{}) {
+	l.log(ErrorLevel, msg, kv)
+}
+
+// Print logs at info level
+func (l *Log) Print(v ...interface{}) {
+	l.log(InfoLevel, fmt.Sprint(v...), nil)
+}
+
+// Printf logs at info level
+func (l *Log) Printf(format string, v ...interface{}) {
+	l.log(InfoLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Println logs at info level
+func (l *Log) Println(v ...interface{}) {
+	l.log(InfoLevel, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Fatal logs at error level then exit
+func (l *Log) Fatal(v ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprint(v...), nil)
+	os.Exit(1)
+}
+
+// Fatalf logs at error level then exit
+func (l *Log) Fatalf(format string, v ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprintf(format, v...), nil)
+	os.Exit(1)
+}
+
+// Fatalln logs at error level then exit
+func (l *Log) Fatalln(v ...interface{}) {
+	l.log(ErrorLevel, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+	os.Exit(1)
+}
+
+// Panic logs at error level then panic
+func (l *Log) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	l.log(ErrorLevel, msg, nil)
+	panic(msg)
+}
+
+// Panicf logs at error level then panic
+func (l *Log) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.log(ErrorLevel, msg, nil)
+	panic(msg)
+}
+
+// Panicln logs at error level then panic
+func (l *Log) Panicln(v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+	l.log(ErrorLevel, msg, nil)
+	panic(msg)
+}
+
+// AsLevelLogger returns l if it is a LevelLogger, otherwise wraps l,
+// entries below level are dropped and others are printed by l.Print with console format.
+func AsLevelLogger(l Logger, level Level) LevelLogger {
+	if ll, ok := l.(LevelLogger); ok {
+		return ll
+	}
+	lv := int32(level)
+	return &levelAdapter{logger: l, level: &lv}
+}
+
+// levelAdapter adapts Logger to LevelLogger
+type levelAdapter struct {
+	logger Logger
+	level  *int32
+	fields []interface{}
+}
+
+// Level returns the level of the logger
+func (a *levelAdapter) Level() Level {
+	return Level(atomic.LoadInt32(a.level))
+}
+
+// SetLevel changes level of the logger and loggers created by With
+func (a *levelAdapter) SetLevel(level Level) {
+	atomic.StoreInt32(a.level, int32(level))
+}
+
+// Enabled returns whether the level is logged
+func (a *levelAdapter) Enabled(level Level) bool {
+	return level >= a.Level()
+}
+
+// log prints the entry without time, which is added by logger
+func (a *levelAdapter) log(level Level, msg string, kv []interface{}) {
+	if !a.Enabled(level) {
+		return
+	}
+	e := &Entry{Level: level, Message: msg, Fields: append(append([]interface{}{}, a.fields...), kv...)}
+	var buf bytes.Buffer
+	ConsoleEncoder{NoTime: true}.Encode(&buf, e)
+	a.logger.Print(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+func (a *levelAdapter) Debug(msg string, kv ...interface{}) { a.log(DebugLevel, msg, kv) }
+func (a *levelAdapter) Info(msg string, kv ...interface{})  { a.log(InfoLevel, msg, kv) }
+func (a *levelAdapter) Warn(msg string, kv ...interface{})  { a.log(WarnLevel, msg, kv) }
+func (a *levelAdapter) Error(msg string, kv ...interface{}) { a.log(ErrorLevel, msg, kv) }
+
+// With returns a logger with the fields added to every entry
+func (a *levelAdapter) With(kv ...interface{}) LevelLogger {
+	return &levelAdapter{logger: a.logger, level: a.level, fields: append(append([]interface{}{}, a.fields...), kv...)}
+}
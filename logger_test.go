@@ -1 +1,147 @@
 package nice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLogger1(t *testing.T) {
+	Convey("leveled logger", t, func() {
+		var buf bytes.Buffer
+		Convey("json encoder", func() {
+			l := NewLog(&buf, InfoLevel, JSONEncoder{})
+			l.Debug("hidden")
+			l.With("app", "nice").Info("hello", "n", 1, "err", errors.New("oops"), "d", time.Second)
+			So(strings.Count(buf.String(), "\n"), ShouldEqual, 1)
+
+			var m map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+			So(m["level"], ShouldEqual, "info")
+			So(m["msg"], ShouldEqual, "hello")
+			So(m["app"], ShouldEqual, "nice")
+			So(m["n"], ShouldEqual, 1)
+			So(m["err"], ShouldEqual, "oops")
+			So(m["d"], ShouldEqual, "1s")
+			So(m["time"], ShouldNotBeEmpty)
+		})
+		Convey("set level", func() {
+			l := NewLog(&buf, WarnLevel, ConsoleEncoder{NoTime: true})
+			child := l.With("k", "v")
+			child.Info("hidden")
+			So(buf.String(), ShouldBeEmpty)
+			l.SetLevel(DebugLevel)
+			So(l.Enabled(DebugLevel), ShouldBeTrue)
+			child.Debug("shown")
+			So(buf.String(), ShouldEqual, "DEBUG shown k=v\n")
+		})
+		Convey("console encoder", func() {
+			l := NewLog(&buf, InfoLevel, nil)
+			l.Error("failed", "path", "/a b", "empty", "", "odd")
+			line := buf.String()
+			So(line[:4], ShouldEqual, time.Now().Format("2006"))
+			So(line, ShouldEndWith, ` ERROR failed path="/a b" empty="" !BADKEY=odd`+"\n")
+
+			buf.Reset()
+			l.Info("login\nINFO forged", "user", "a\nb", "k\r", "v")
+			So(buf.String(), ShouldEndWith, ` INFO "login\nINFO forged" user="a\nb" "k\r"=v`+"\n")
+			So(strings.Count(buf.String(), "\n"), ShouldEqual, 1)
+
+			buf.Reset()
+			l.Printf("%d items", 3)
+			So(buf.String(), ShouldEndWith, " INFO 3 items\n")
+			So(func() { l.Panic("boom") }, ShouldPanicWith, "boom")
+		})
+		Convey("adapter of Logger", func() {
+			l := AsLevelLogger(log.New(&buf, "", 0), InfoLevel)
+			child := l.With("a", 1)
+			child.Warn("slow", "ms", 200)
+			So(buf.String(), ShouldEqual, "WARN slow a=1 ms=200\n")
+
+			buf.Reset()
+			child.Debug("hidden")
+			So(buf.String(), ShouldBeEmpty)
+			l.(*levelAdapter).SetLevel(DebugLevel)
+			child.Debug("shown")
+			So(buf.String(), ShouldEqual, "DEBUG shown a=1\n")
+
+			nl := NewLog(&buf, InfoLevel, nil)
+			So(AsLevelLogger(nl, DebugLevel), ShouldPointTo, nl)
+		})
+		Convey("parse level", func() {
+			lv, err := ParseLevel("WARNING")
+			So(err, ShouldBeNil)
+			So(lv, ShouldEqual, WarnLevel)
+			So(DebugLevel.String(), ShouldEqual, "debug")
+			_, err = ParseLevel("verbose")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestLogger2(t *testing.T) {
+	Convey("request logger", t, func() {
+		var buf bytes.Buffer
+		b2 := New()
+		b2.SetDI("logger", NewLog(&buf, InfoLevel, JSONEncoder{}))
+		b2.Get("/users/:id", func(c *Context) {
+			c.Log().Info("get user", "id", c.Param("id"))
+		}).Name("user")
+		b2.Get("/logs", func(c *Context) {
+			c.Log().Info("list")
+		})
+
+		req, _ := http.NewRequest("GET", "/users/1", nil)
+		req.Header.Set("X-Request-ID", "abc")
+		req.RemoteAddr = "10.0.0.1:1234"
+		b2.ServeHTTP(httptest.NewRecorder(), req)
+		var m map[string]interface{}
+		So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+		So(m["request_id"], ShouldEqual, "abc")
+		So(m["route"], ShouldEqual, "user")
+		So(m["remote_addr"], ShouldEqual, "10.0.0.1")
+		So(m["id"], ShouldEqual, "1")
+
+		buf.Reset()
+		req, _ = http.NewRequest("GET", "/logs", nil)
+		b2.ServeHTTP(httptest.NewRecorder(), req)
+		m = nil
+		So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+		So(m["route"], ShouldEqual, "/logs")
+		So(m, ShouldNotContainKey, "request_id")
+
+		// invalid request id of client is not used
+		buf.Reset()
+		req, _ = http.NewRequest("GET", "/logs", nil)
+		req.Header.Set("X-Request-ID", "abc\x1b[31m")
+		b2.ServeHTTP(httptest.NewRecorder(), req)
+		m = nil
+		So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+		So(m, ShouldNotContainKey, "request_id")
+
+		buf.Reset()
+		b2.Get("/rid", func(c *Context) {
+			c.SetRequestID("generated")
+			So(c.RequestID(), ShouldEqual, "generated")
+			So(RequestIDFromContext(c.Ctx()), ShouldEqual, "generated")
+			c.Log().Info("rid")
+		})
+		req, _ = http.NewRequest("GET", "/rid", nil)
+		req.Header.Set("X-Request-ID", "abc")
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Header().Get("X-Request-ID"), ShouldEqual, "generated")
+		m = nil
+		So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+		So(m["request_id"], ShouldEqual, "generated")
+		So(RequestIDFromContext(req.Context()), ShouldBeEmpty)
+	})
+}
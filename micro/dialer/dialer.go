@@ -1,17 +1,26 @@
 package dialer
 
 import (
+	"context"
 	"fmt"
 	"github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
+	"github.com/nic-chen/nice"
 	"github.com/nic-chen/nice/micro/tracing"
 	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// RequestIDKey is the metadata key of request id, see nice.RequestIDFromContext
+const RequestIDKey = "x-request-id"
+
 //
 type Options struct {
 	Tracer                  opentracing.Tracer
+	TracerProvider          trace.TracerProvider
 	UnaryClientInterceptors []grpc.UnaryClientInterceptor
 	DialOptions             []grpc.DialOption
 	TraceIdFunc             tracing.ClientTraceIdFunc
@@ -29,6 +38,14 @@ func WithTracer(t opentracing.Tracer) Option {
 	}
 }
 
+// WithTracerProvider traces rpc calls by OpenTelemetry, the span of ctx is the parent,
+// like c.Ctx() of nice handler under Tracing middleware, it is propagated by W3C traceparent.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(options *Options) {
+		options.TracerProvider = tp
+	}
+}
+
 func WithDialOption(gopts ...grpc.DialOption) Option {
 	return func(options *Options) {
 		options.DialOptions = gopts
@@ -49,6 +66,9 @@ func Dial(name string, opts ...Option) (*grpc.ClientConn, error) {
 		v(&options)
 	}
 
+	// request id is forwarded before other interceptors
+	options.UnaryClientInterceptors = append([]grpc.UnaryClientInterceptor{RequestIDInterceptor}, options.UnaryClientInterceptors...)
+
 	if options.TraceIdFunc != nil {
 		options.UnaryClientInterceptors = append(options.UnaryClientInterceptors, tracing.UnaryClientInterceptor(options.TraceIdFunc))
 	}
@@ -58,6 +78,13 @@ func Dial(name string, opts ...Option) (*grpc.ClientConn, error) {
 		options.UnaryClientInterceptors = append(options.UnaryClientInterceptors, grpc_opentracing.UnaryClientInterceptor(grpc_opentracing.WithTracer(options.Tracer)))
 	}
 
+	if options.TracerProvider != nil {
+		options.DialOptions = append(options.DialOptions, grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithTracerProvider(options.TracerProvider),
+			otelgrpc.WithPropagators(nice.TracePropagator()),
+		)))
+	}
+
 	uopt := grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(options.UnaryClientInterceptors...))
 
 	conn, err := grpc.Dial(name, append(options.DialOptions, uopt)...)
@@ -73,3 +100,14 @@ func WithUnaryClientInterceptor(interceptors ...grpc.UnaryClientInterceptor) Opt
 		options.UnaryClientInterceptors = append(options.UnaryClientInterceptors, interceptors...)
 	}
 }
+
+// RequestIDInterceptor forwards request id carried by ctx as metadata,
+// ctx of nice handler carries it when RequestID middleware is used.
+func RequestIDInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := nice.RequestIDFromContext(ctx); id != "" {
+		if md, ok := metadata.FromOutgoingContext(ctx); !ok || len(md.Get(RequestIDKey)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDKey, id)
+		}
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
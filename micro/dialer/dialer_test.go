@@ -1,9 +1,13 @@
 package dialer_test
 
 import (
+	"context"
+	"github.com/nic-chen/nice"
 	"github.com/nic-chen/nice/micro/dialer"
 	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace/noop"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +35,15 @@ func TestWithTracer(t *testing.T) {
 	}
 }
 
+func TestWithTracerProvider(t *testing.T) {
+	o := dialer.WithTracerProvider(noop.NewTracerProvider())
+	os := &dialer.Options{}
+	o(os)
+	if os.TracerProvider == nil {
+		t.Errorf("dialer with tracer provider fail")
+	}
+}
+
 func TestWithDialOption(t *testing.T) {
 	o := dialer.WithDialOption(grpc.WithInsecure())
 	os := &dialer.Options{}
@@ -57,3 +70,17 @@ func TestDial(t *testing.T) {
 	}
 	t.Errorf("dial get err:%s", err)
 }
+
+func TestRequestIDInterceptor(t *testing.T) {
+	ctx := nice.WithRequestID(context.Background(), "abc")
+	err := dialer.RequestIDInterceptor(ctx, "/test", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if ids := md.Get(dialer.RequestIDKey); len(ids) != 1 || ids[0] != "abc" {
+			t.Errorf("request id not forwarded: %v", ids)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("invoke get err:%s", err)
+	}
+}
@@ -0,0 +1,105 @@
+package micro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nic-chen/nice"
+	"go.uber.org/zap"
+)
+
+// ZapLogger adapts zap.Logger to nice.Logger and nice.LevelLogger, so the
+// HTTP side of nice and the gRPC side share one logger
+//
+// Example:
+//	zl, _ := zap.NewProduction()
+//	app.SetDI("logger", micro.NewZapLogger(zl))
+//	srv, _ := micro.NewServer("user", micro.WithLogger(zl))
+type ZapLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewZapLogger create an adapter of zap logger
+func NewZapLogger(l *zap.Logger) *ZapLogger {
+	return &ZapLogger{sugar: l.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+// Debug logs at debug level with key-value pairs
+func (l *ZapLogger) Debug(msg string, kv ...interface{}) {
+	l.sugar.Debugw(msg, kv...)
+}
+
+// Info logs at info level with key-value pairs
+func (l *ZapLogger) Info(msg string, kv ...interface{}) {
+	l.sugar.Infow(msg, kv...)
+}
+
+// Warn logs at warn level with key-value pairs
+func (l *ZapLogger) Warn(msg string, kv ...interface{}) {
+	l.sugar.Warnw(msg, kv...)
+}
+
+// Error logs at error level with key-value pairs
+func (l *ZapLogger) Error(msg string, kv ...interface{}) {
+	l.sugar.Errorw(msg, kv...)
+}
+
+// With returns a logger with the fields added to every entry
+func (l *ZapLogger) With(kv ...interface{}) nice.LevelLogger {
+	return &ZapLogger{sugar: l.sugar.With(kv...)}
+}
+
+// Print logs at info level
+func (l *ZapLogger) Print(v ...interface{}) {
+	l.sugar.Info(v...)
+}
+
+// Printf logs at info level
+func (l *ZapLogger) Printf(format string, v ...interface{}) {
+	l.sugar.Infof(format, v...)
+}
+
+// Println logs at info level
+func (l *ZapLogger) Println(v ...interface{}) {
+	l.sugar.Info(sprintln(v...))
+}
+
+// Fatal logs at fatal level then exit
+func (l *ZapLogger) Fatal(v ...interface{}) {
+	l.sugar.Fatal(v...)
+}
+
+// Fatalf logs at fatal level then exit
+func (l *ZapLogger) Fatalf(format string, v ...interface{}) {
+	l.sugar.Fatalf(format, v...)
+}
+
+// Fatalln logs at fatal level then exit
+func (l *ZapLogger) Fatalln(v ...interface{}) {
+	l.sugar.Fatal(sprintln(v...))
+}
+
+// Panic logs at panic level then panic
+func (l *ZapLogger) Panic(v ...interface{}) {
+	l.sugar.Panic(v...)
+}
+
+// Panicf logs at panic level then panic
+func (l *ZapLogger) Panicf(format string, v ...interface{}) {
+	l.sugar.Panicf(format, v...)
+}
+
+// Panicln logs at panic level then panic
+func (l *ZapLogger) Panicln(v ...interface{}) {
+	l.sugar.Panic(sprintln(v...))
+}
+
+// Sync flushes buffered logs
+func (l *ZapLogger) Sync() error {
+	return l.sugar.Sync()
+}
+
+// sprintln formats like fmt.Sprintln without the new line
+func sprintln(v ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+}
@@ -3,8 +3,10 @@ package micro
 import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	"github.com/nic-chen/nice"
 	"github.com/nic-chen/nice/micro/registry"
 	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"log"
@@ -14,7 +16,9 @@ import (
 
 type serverOptions struct {
 	tracer                   opentracing.Tracer
+	tracerProvider           trace.TracerProvider
 	logger                   *zap.Logger
+	levelLogger              nice.LevelLogger
 	unaryServerInterceptors  []grpc.UnaryServerInterceptor
 	streamServerInterceptors []grpc.StreamServerInterceptor
 	authFunc                 grpc_auth.AuthFunc
@@ -42,12 +46,29 @@ func WithLogger(logger *zap.Logger) Option {
 	}
 }
 
+// WithLevelLogger logs by nice.LevelLogger, like the logger of nice application,
+// it is used instead of zap logger set by WithLogger
+func WithLevelLogger(logger nice.LevelLogger) Option {
+	return func(options *serverOptions) {
+		options.levelLogger = logger
+	}
+}
+
 func WithTracer(tracer opentracing.Tracer) Option {
 	return func(options *serverOptions) {
 		options.tracer = tracer
 	}
 }
 
+// WithTracerProvider traces rpc calls by OpenTelemetry, the parent span is extracted
+// from W3C traceparent in metadata, like calls of dialer with WithTracerProvider.
+// it is used instead of opentracing tracer set by WithTracer.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(options *serverOptions) {
+		options.tracerProvider = tp
+	}
+}
+
 func WithUnaryServerInterceptor(intercoptors ...grpc.UnaryServerInterceptor) Option {
 	return func(options *serverOptions) {
 		options.unaryServerInterceptors = append(options.unaryServerInterceptors, intercoptors...)
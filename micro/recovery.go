@@ -19,7 +19,9 @@ var (
 
 func RecoveryWithLogger(options *serverOptions) grpc_recovery.RecoveryHandlerFunc {
 	return func(p interface{}) (err error) {
-		if options.logger != nil {
+		if options.levelLogger != nil {
+			options.levelLogger.Error("[recovery] panic recovered", "panic", p, "stack", string(stack(3)))
+		} else if options.logger != nil {
 			stack := stack(3)
 			options.logger.Sugar().Errorf("[recovery] panic recovered:\n%s\n%s", p, stack)
 		}
@@ -0,0 +1,21 @@
+package micro
+
+import (
+	"context"
+
+	"github.com/nic-chen/nice"
+	"github.com/nic-chen/nice/micro/dialer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDInterceptor carries request id from metadata forwarded by dialer into
+// the context, it can be read by nice.RequestIDFromContext and forwarded again.
+func RequestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(dialer.RequestIDKey); len(ids) > 0 && ids[0] != "" {
+			ctx = nice.WithRequestID(ctx, ids[0])
+		}
+	}
+	return handler(ctx, req)
+}
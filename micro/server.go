@@ -15,6 +15,8 @@ import (
 	//"github.com/nic-chen/nice/micro/registry"
 	//"github.com/nic-chen/nice/micro/tracing"
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+	"github.com/nic-chen/nice"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 )
 
 type Server struct {
@@ -31,6 +33,8 @@ func NewServer(name string, opts ...Option) (*Server, error) {
 		Option: sOptions,
 	}
 
+	sOptions.applyOption(WithUnaryServerInterceptor(RequestIDInterceptor))
+
 	if sOptions.prometheus {
 		sOptions.applyOption(WithUnaryServerInterceptor(grpc_prometheus.UnaryServerInterceptor))
 		sOptions.applyOption(WithStreamServerInterceptor(grpc_prometheus.StreamServerInterceptor))
@@ -55,7 +59,12 @@ func NewServer(name string, opts ...Option) (*Server, error) {
 func (t Server) BuildGrpcServer() *grpc.Server {
 	var opts = t.Option.grpcOptions
 
-	if t.Option.tracer != nil {
+	if t.Option.tracerProvider != nil {
+		opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithTracerProvider(t.Option.tracerProvider),
+			otelgrpc.WithPropagators(nice.TracePropagator()),
+		)))
+	} else if t.Option.tracer != nil {
 		opts = append(opts, grpc.UnaryInterceptor(
 			otgrpc.OpenTracingServerInterceptor(t.Option.tracer),
 		))
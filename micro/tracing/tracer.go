@@ -8,7 +8,9 @@ import (
 	"github.com/uber/jaeger-client-go/config"
 )
 
-// Init returns a newly configured tracer
+// Init returns a newly configured tracer of jaeger by opentracing,
+// tracing.Init of nice with micro.WithTracerProvider is preferred, it traces
+// HTTP requests, db, cache and rpc calls in one trace by OpenTelemetry.
 func Init(serviceName, host string) (opentracing.Tracer, error) {
 	cfg := config.Configuration{
 		Sampler: &config.SamplerConfig{
@@ -13,8 +13,10 @@ A Default Config for example is below:
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	nice "../"
@@ -50,6 +52,10 @@ type Config struct {
 	Origins string
 	origins []string
 
+	// AllowOriginFunc is called to check the origin when it is not in Origins,
+	// use CorsFromConfig to update Origins when config is reloaded.
+	AllowOriginFunc func(origin string) bool
+
 	// This are the headers that the resource supports, and will accept in the request.
 	// Default is "Authorization".
 	RequestHeaders string
@@ -100,10 +106,54 @@ Cors generates a middleware handler function that works inside of a Gin request
 to set the correct CORS headers.  It accepts a cors.Options struct for configuration.
 */
 func Cors(config Config) nice.HandlerFunc {
+	h, err := newCors(config)
+	if err != nil {
+		panic(err.Error())
+	}
+	return h
+}
+
+// CorsFromConfig returns the Cors middleware with Config decoded from key of c,
+// like cors.origins and cors.methods. the middleware is rebuilt when the key
+// is changed by reloading c, and the reloading fails if the new Config is invalid.
+//
+// Example:
+//	app.Use(middleware.CorsFromConfig(app.Config(), "cors"))
+//	app.WatchConfig(5 * time.Second)
+func CorsFromConfig(c *nice.Config, key string) nice.HandlerFunc {
+	build := func(c *nice.Config) (nice.HandlerFunc, error) {
+		var config Config
+		if err := c.Decode(key, &config); err != nil {
+			return nil, err
+		}
+		return newCors(config)
+	}
+	h, err := build(c)
+	if err != nil {
+		panic(err.Error())
+	}
+	var current atomic.Value
+	current.Store(h)
+	c.Validate(func(next *nice.Config) error {
+		_, err := build(next)
+		return err
+	})
+	c.OnChange(key, func(old, new interface{}) {
+		if h, err := build(c); err == nil {
+			current.Store(h)
+		}
+	})
+	return func(ctx *nice.Context) {
+		current.Load().(nice.HandlerFunc)(ctx)
+	}
+}
+
+// newCors returns the Cors middleware, error if config is invalid
+func newCors(config Config) (nice.HandlerFunc, error) {
 	forceOriginMatch := false
 
-	if config.Origins == "" {
-		panic("You must set at least a single valid origin. If you don't want CORS, to apply, simply remove the middleware.")
+	if config.Origins == "" && config.AllowOriginFunc == nil {
+		return nil, errors.New("You must set at least a single valid origin. If you don't want CORS, to apply, simply remove the middleware.")
 	}
 
 	if config.Origins == "*" {
@@ -175,7 +225,7 @@ func Cors(config Config) nice.HandlerFunc {
 		}
 
 		c.Next()
-	}
+	}, nil
 }
 
 func handlePreflight(c *nice.Context, config Config, requestMethod string) bool {
@@ -212,6 +262,9 @@ func matchOrigin(origin string, config Config) bool {
 			return true
 		}
 	}
+	if config.AllowOriginFunc != nil {
+		return config.AllowOriginFunc(origin)
+	}
 	return false
 }
 
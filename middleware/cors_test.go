@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nice "../"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCorsFromConfig1(t *testing.T) {
+	Convey("cors reloaded with config", t, func() {
+		origins := "http://a.com"
+		c := nice.NewConfig(nice.ConfigSourceFunc(func() (map[string]interface{}, error) {
+			return map[string]interface{}{"cors": map[string]interface{}{"origins": origins, "maxage": "1m"}}, nil
+		}))
+		So(c.Load(), ShouldBeNil)
+
+		app := nice.New()
+		app.Use(CorsFromConfig(c, "cors"))
+		app.Get("/", func(c *nice.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		allowed := func(origin string) string {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(OriginKey, origin)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			return w.Header().Get(AllowOriginKey)
+		}
+		So(allowed("http://a.com"), ShouldEqual, "http://a.com")
+		So(allowed("http://b.com"), ShouldEqual, "")
+
+		origins = "http://b.com"
+		So(c.Load(), ShouldBeNil)
+		So(allowed("http://a.com"), ShouldEqual, "")
+		So(allowed("http://b.com"), ShouldEqual, "http://b.com")
+
+		// invalid config is rejected
+		origins = ""
+		So(c.Load(), ShouldNotBeNil)
+		So(allowed("http://b.com"), ShouldEqual, "http://b.com")
+
+		So(func() { CorsFromConfig(nice.NewConfig(), "cors") }, ShouldPanic)
+	})
+}
@@ -2,18 +2,356 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	nice "../"
 )
 
+// formats of access log
+const (
+	// LogFormatDefault is "remote_ip method uri status latency", printed by nice logger
+	LogFormatDefault = ""
+	// LogFormatCommon is Apache common log format
+	LogFormatCommon = `${remote_ip} - ${user} [${time_clf}] "${method} ${uri} ${proto}" ${status} ${bytes_out_clf}`
+	// LogFormatCombined is Apache combined log format
+	LogFormatCombined = LogFormatCommon + ` "${header:Referer}" "${header:User-Agent}"`
+	// LogFormatJSON logs a json object per line
+	LogFormatJSON = "json"
+)
+
+// LoggerConfig defines the config of Logger middleware
+type LoggerConfig struct {
+	// Format is LogFormatDefault, LogFormatCommon, LogFormatCombined, LogFormatJSON
+	// or a template with tags, like "${remote_ip} ${method} ${uri} ${status}".
+	// tags are:
+	//	time, time_clf, time_unix, remote_ip, user, host, method, uri, path, route,
+	//	proto, status, latency, latency_human, bytes_in, bytes_out, bytes_out_clf,
+	//	request_id, error, header:<name>, resp_header:<name>, query:<name>
+	Format string
+
+	// Output is the writer of log lines, default is the logger of nice
+	Output io.Writer
+
+	// Headers are request headers added to json log, named by lower case header
+	Headers []string
+	// ResponseHeaders are response headers added to json log, named by lower case header with resp_ prefix
+	ResponseHeaders []string
+
+	// SkipPaths are not logged, path ends with * is a prefix, like /health or /static/*
+	SkipPaths []string
+	// Skip returns true when the request should not be logged
+	Skip func(c *nice.Context) bool
+
+	// SampleRate logs the rate of requests between 0 and 1, other values log all requests,
+	// errors and responses with status >= 500 are always logged.
+	SampleRate float64
+}
+
 // Logger returns a nice middleware for log http access
 func Logger() nice.HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+// LoggerWithConfig returns a nice middleware for log http access with config
+//
+// Example:
+//	app.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+//		Format:    middleware.LogFormatCombined,
+//		Output:    &nice.RotateFile{Filename: "logs/access.log", Interval: 24 * time.Hour},
+//		SkipPaths: []string{"/health"},
+//	}))
+func LoggerWithConfig(config LoggerConfig) nice.HandlerFunc {
+	var format func(buf *bytes.Buffer, c *nice.Context, start time.Time)
+	switch config.Format {
+	case LogFormatDefault:
+		format = formatDefault
+	case LogFormatJSON:
+		format = config.formatJSON
+	default:
+		format = parseLogTemplate(config.Format)
+	}
+
+	var mutex sync.Mutex
 	return func(c *nice.Context) {
+		if config.skip(c) {
+			c.Next()
+			return
+		}
 		start := time.Now()
 
 		c.Next()
 
-		c.Nice().Logger().Printf("%s %s %s %v %v", c.RemoteAddr(), c.Req.Method, c.URL(false), c.Resp.Status(), time.Since(start))
+		if !config.sample(c) {
+			return
+		}
+		var buf bytes.Buffer
+		format(&buf, c, start)
+		if config.Output == nil {
+			c.Nice().Logger().Print(buf.String())
+			return
+		}
+		buf.WriteByte('\n')
+		mutex.Lock()
+		config.Output.Write(buf.Bytes())
+		mutex.Unlock()
+	}
+}
+
+// skip checks SkipPaths and Skip
+func (config *LoggerConfig) skip(c *nice.Context) bool {
+	path := c.Req.URL.Path
+	for _, p := range config.SkipPaths {
+		if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, p[:len(p)-1])) {
+			return true
+		}
+	}
+	return config.Skip != nil && config.Skip(c)
+}
+
+// sample returns whether the request is logged by SampleRate
+func (config *LoggerConfig) sample(c *nice.Context) bool {
+	if config.SampleRate <= 0 || config.SampleRate >= 1 {
+		return true
+	}
+	if c.Err() != nil || c.Resp.Status() >= http.StatusInternalServerError {
+		return true
+	}
+	return rand.Float64() < config.SampleRate
+}
+
+// formatDefault formats like "remote_ip method uri status latency request_id=id"
+func formatDefault(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+	buf.WriteString(c.RemoteAddr())
+	buf.WriteByte(' ')
+	buf.WriteString(c.Req.Method)
+	buf.WriteByte(' ')
+	buf.WriteString(c.URL(false))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(c.Resp.Status()))
+	buf.WriteByte(' ')
+	buf.WriteString(time.Since(start).String())
+	if id := c.RequestID(); id != "" {
+		buf.WriteString(" request_id=")
+		buf.WriteString(id)
 	}
 }
+
+// formatJSON formats a json object
+func (config *LoggerConfig) formatJSON(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+	latency := time.Since(start)
+	fields := []interface{}{
+		"time", start.Format(time.RFC3339Nano),
+		"remote_ip", c.RemoteAddr(),
+		"host", c.Req.Host,
+		"method", c.Req.Method,
+		"uri", logURI(c),
+		"path", c.Req.URL.Path,
+		"route", logRoute(c),
+		"proto", c.Req.Proto,
+		"status", c.Resp.Status(),
+		"latency", int64(latency),
+		"latency_human", latency.String(),
+		"bytes_in", logBytesIn(c),
+		"bytes_out", c.Resp.Size(),
+		"user_agent", c.Req.UserAgent(),
+		"referer", c.Req.Referer(),
+	}
+	if id := c.RequestID(); id != "" {
+		fields = append(fields, "request_id", id)
+	}
+	if err := c.Err(); err != nil {
+		fields = append(fields, "error", err.Error())
+	}
+	for _, h := range config.Headers {
+		fields = append(fields, strings.ToLower(h), c.Req.Header.Get(h))
+	}
+	for _, h := range config.ResponseHeaders {
+		fields = append(fields, "resp_"+strings.ToLower(h), c.Resp.Header().Get(h))
+	}
+
+	buf.WriteByte('{')
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(fields[i])
+		v, _ := json.Marshal(fields[i+1])
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+}
+
+// logRoute returns the route name, or the pattern if route has no name
+func logRoute(c *nice.Context) string {
+	if name := c.RouteName(); name != "" {
+		return name
+	}
+	return c.RoutePattern()
+}
+
+// logURI returns the request uri, it is built from URL when not set
+func logURI(c *nice.Context) string {
+	if c.Req.RequestURI != "" {
+		return c.Req.RequestURI
+	}
+	return c.Req.URL.RequestURI()
+}
+
+// logBytesIn returns size of request body, 0 if unknown
+func logBytesIn(c *nice.Context) int64 {
+	if c.Req.ContentLength < 0 {
+		return 0
+	}
+	return c.Req.ContentLength
+}
+
+// parseLogTemplate parses template into writers of literal text and tags
+func parseLogTemplate(tpl string) func(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+	type writer func(buf *bytes.Buffer, c *nice.Context, start time.Time)
+	var writers []writer
+	for tpl != "" {
+		i := strings.Index(tpl, "${")
+		j := -1
+		if i >= 0 {
+			j = strings.IndexByte(tpl[i:], '}')
+		}
+		if i < 0 || j < 0 {
+			text := tpl
+			writers = append(writers, func(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+				buf.WriteString(text)
+			})
+			break
+		}
+		if i > 0 {
+			text := tpl[:i]
+			writers = append(writers, func(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+				buf.WriteString(text)
+			})
+		}
+		tag := tpl[i+2 : i+j]
+		writers = append(writers, func(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+			buf.WriteString(logTag(tag, c, start))
+		})
+		tpl = tpl[i+j+1:]
+	}
+	return func(buf *bytes.Buffer, c *nice.Context, start time.Time) {
+		for _, w := range writers {
+			w(buf, c, start)
+		}
+	}
+}
+
+// logTag returns value of the template tag, missing header is "-"
+func logTag(tag string, c *nice.Context, start time.Time) string {
+	switch tag {
+	case "time":
+		return start.Format(time.RFC3339)
+	case "time_clf":
+		return start.Format("02/Jan/2006:15:04:05 -0700")
+	case "time_unix":
+		return strconv.FormatInt(start.Unix(), 10)
+	case "remote_ip":
+		if ip := c.RemoteAddr(); ip != "" {
+			return ip
+		}
+		return "-"
+	case "user":
+		if c.Req.URL.User != nil && c.Req.URL.User.Username() != "" {
+			return logEscape(c.Req.URL.User.Username())
+		}
+		if user, _, ok := c.Req.BasicAuth(); ok && user != "" {
+			return logEscape(user)
+		}
+		return "-"
+	case "host":
+		return c.Req.Host
+	case "method":
+		return c.Req.Method
+	case "uri":
+		return logURI(c)
+	case "path":
+		return c.Req.URL.Path
+	case "route":
+		return logRoute(c)
+	case "proto":
+		return c.Req.Proto
+	case "status":
+		return strconv.Itoa(c.Resp.Status())
+	case "latency":
+		return strconv.FormatInt(int64(time.Since(start)), 10)
+	case "latency_human":
+		return time.Since(start).String()
+	case "bytes_in":
+		return strconv.FormatInt(logBytesIn(c), 10)
+	case "bytes_out":
+		return strconv.FormatInt(c.Resp.Size(), 10)
+	case "bytes_out_clf":
+		if c.Resp.Size() == 0 {
+			return "-"
+		}
+		return strconv.FormatInt(c.Resp.Size(), 10)
+	case "request_id":
+		return c.RequestID()
+	case "error":
+		if err := c.Err(); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	var v string
+	switch {
+	case strings.HasPrefix(tag, "header:"):
+		v = c.Req.Header.Get(tag[7:])
+	case strings.HasPrefix(tag, "resp_header:"):
+		v = c.Resp.Header().Get(tag[12:])
+	case strings.HasPrefix(tag, "query:"):
+		v = c.Req.URL.Query().Get(tag[6:])
+	default:
+		return "${" + tag + "}"
+	}
+	if v == "" {
+		return "-"
+	}
+	return logEscape(v)
+}
+
+// logEscape escapes quote and backslash with backslash, control and non-ASCII
+// characters as \xHH like Apache, so that values from clients can not forge fields or lines.
+func logEscape(s string) string {
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b >= 0x20 && b < 0x7f && b != '"' && b != '\\' {
+			if buf != nil {
+				buf = append(buf, b)
+			}
+			continue
+		}
+		if buf == nil {
+			buf = append(make([]byte, 0, len(s)+8), s[:i]...)
+		}
+		switch b {
+		case '"', '\\':
+			buf = append(buf, '\\', b)
+		default:
+			buf = append(buf, '\\', 'x', hexDigits[b>>4], hexDigits[b&0xf])
+		}
+	}
+	if buf == nil {
+		return s
+	}
+	return string(buf)
+}
+
+const hexDigits = "0123456789abcdef"
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	nice "../"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// logRequest serves a request by app with access log of config, returns the log line
+func logRequest(config LoggerConfig, method, target string) string {
+	var buf bytes.Buffer
+	if config.Output == nil {
+		config.Output = &buf
+	}
+	app := nice.New()
+	app.Use(RequestID(), LoggerWithConfig(config))
+	app.Get("/users/:id", func(c *nice.Context) {
+		c.Resp.Header().Set("X-Cache", "hit")
+		c.String(http.StatusOK, "hello")
+	}).Name("user")
+	app.Post("/users", func(c *nice.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+	app.Delete("/users/:id", func(c *nice.Context) {
+		c.Resp.WriteHeader(http.StatusNoContent)
+	})
+	app.Get("/fail", func(c *nice.Context) {
+		c.Error(errors.New("BOMB"))
+	})
+	app.Get("/health", func(c *nice.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	var body *strings.Reader
+	if method == "POST" {
+		body = strings.NewReader("name=nice")
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+	req.Header.Set("Referer", "http://a.com/")
+	req.Header.Set("User-Agent", "nice-test")
+	req.SetBasicAuth("bob", "secret")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+	return buf.String()
+}
+
+func TestLogger1(t *testing.T) {
+	Convey("access log formats and tags", t, func() {
+		for _, tt := range []struct {
+			format string
+			method string
+			target string
+			want   string
+		}{
+			{"${remote_ip} ${user} ${host} ${method} ${uri} ${path} ${route} ${proto}", "GET", "/users/1?q=x",
+				"10.0.0.1 bob example.com GET /users/1?q=x /users/1 user HTTP/1.1\n"},
+			{"${status} ${bytes_in} ${bytes_out} ${bytes_out_clf} ${request_id} ${error}", "GET", "/users/1",
+				"200 0 5 5 req-1 \n"},
+			{"${status} ${bytes_in} ${bytes_out} ${route}", "POST", "/users",
+				"201 9 7 /users\n"},
+			{"${status} ${bytes_out_clf} ${error}", "GET", "/fail",
+				"500 22 BOMB\n"},
+			{"${status} ${bytes_out} ${bytes_out_clf}", "DELETE", "/users/1",
+				"204 0 -\n"},
+			{"${header:Referer} ${header:X-None} ${resp_header:X-Cache} ${query:q} ${query:none}", "GET", "/users/1?q=x",
+				"http://a.com/ - hit x -\n"},
+			{"${route}|${unknown}|${", "GET", "/none", "|${unknown}|${\n"},
+			{LogFormatCommon, "GET", "/users/1",
+				`^10\.0\.0\.1 - bob \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users/1 HTTP/1\.1" 200 5` + "\n$"},
+			{LogFormatCombined, "GET", "/users/1",
+				`" 200 5 "http://a\.com/" "nice-test"` + "\n$"},
+			{"${time} ${time_unix} ${latency} ${latency_human}", "GET", "/users/1",
+				`^\d{4}-\d{2}-\d{2}T\S+ \d+ \d+ \S+s` + "\n$"},
+			{`"${query:q}" ${resp_header:X-Cache} ${user}`, "GET", "/users/1?q=a%22b%0A1.2.3.4+-%5C%C3%A9",
+				`"a\"b\x0a1.2.3.4 -\\\xc3\xa9" hit bob` + "\n"},
+		} {
+			got := logRequest(LoggerConfig{Format: tt.format}, tt.method, tt.target)
+			if strings.HasSuffix(tt.want, "$") {
+				So(got, ShouldNotBeEmpty)
+				So(regexp.MustCompile(tt.want).MatchString(got), ShouldBeTrue)
+			} else {
+				So(got, ShouldEqual, tt.want)
+			}
+		}
+	})
+	Convey("json format", t, func() {
+		got := logRequest(LoggerConfig{
+			Format:          LogFormatJSON,
+			Headers:         []string{"Referer"},
+			ResponseHeaders: []string{"X-Cache"},
+		}, "GET", "/users/1?q=x")
+		var m map[string]interface{}
+		So(json.Unmarshal([]byte(got), &m), ShouldBeNil)
+		So(m["remote_ip"], ShouldEqual, "10.0.0.1")
+		So(m["method"], ShouldEqual, "GET")
+		So(m["uri"], ShouldEqual, "/users/1?q=x")
+		So(m["path"], ShouldEqual, "/users/1")
+		So(m["route"], ShouldEqual, "user")
+		So(m["status"], ShouldEqual, 200)
+		So(m["bytes_out"], ShouldEqual, 5)
+		So(m["request_id"], ShouldEqual, "req-1")
+		So(m["referer"], ShouldEqual, "http://a.com/")
+		So(m["resp_x-cache"], ShouldEqual, "hit")
+		So(m, ShouldNotContainKey, "error")
+
+		got = logRequest(LoggerConfig{Format: LogFormatJSON}, "GET", "/fail")
+		So(json.Unmarshal([]byte(got), &m), ShouldBeNil)
+		So(m["error"], ShouldEqual, "BOMB")
+	})
+	Convey("default format by nice logger", t, func() {
+		var buf bytes.Buffer
+		app := nice.New()
+		app.SetDI("logger", log.New(&buf, "", 0))
+		app.Use(RequestID(), Logger())
+		app.Get("/users/:id", func(c *nice.Context) {
+			c.String(http.StatusOK, "hello")
+		})
+		req := httptest.NewRequest("GET", "/users/1?q=x", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+		app.ServeHTTP(httptest.NewRecorder(), req)
+		So(buf.String(), ShouldStartWith, "10.0.0.1 GET http://example.com/users/1 200 ")
+		So(buf.String(), ShouldEndWith, " request_id=req-1\n")
+	})
+	Convey("skip and sample", t, func() {
+		format := "${path} ${status}"
+		So(logRequest(LoggerConfig{Format: format, SkipPaths: []string{"/health"}}, "GET", "/health"), ShouldBeEmpty)
+		So(logRequest(LoggerConfig{Format: format, SkipPaths: []string{"/users/*"}}, "GET", "/users/1"), ShouldBeEmpty)
+		So(logRequest(LoggerConfig{Format: format, SkipPaths: []string{"/users/*"}}, "GET", "/health"), ShouldEqual, "/health 200\n")
+		skip := func(c *nice.Context) bool { return c.Req.Method == "POST" }
+		So(logRequest(LoggerConfig{Format: format, Skip: skip}, "POST", "/users"), ShouldBeEmpty)
+		So(logRequest(LoggerConfig{Format: format, Skip: skip}, "GET", "/health"), ShouldEqual, "/health 200\n")
+
+		// errors are always logged, 1 and 0 log all
+		sampled := LoggerConfig{Format: format, SampleRate: 0.000001}
+		So(logRequest(sampled, "GET", "/fail"), ShouldEqual, "/fail 500\n")
+		n := 0
+		for i := 0; i < 20; i++ {
+			if logRequest(sampled, "GET", "/health") != "" {
+				n++
+			}
+		}
+		So(n, ShouldBeLessThan, 20)
+		So(logRequest(LoggerConfig{Format: format, SampleRate: 1}, "GET", "/health"), ShouldEqual, "/health 200\n")
+	})
+}
@@ -0,0 +1,213 @@
+// Package metrics provider a nice middleware for prometheus metrics.
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	nice "../"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusConfig defines the config of Prometheus middleware
+type PrometheusConfig struct {
+	// Namespace of metrics, default is nice
+	Namespace string
+	// Subsystem of metrics, default is http
+	Subsystem string
+	// Registerer registers the metrics, default is prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+	// Buckets of request duration in seconds, default is prometheus.DefBuckets
+	Buckets []float64
+	// SizeBuckets of response size in bytes, default is 100B to 100MB
+	SizeBuckets []float64
+	// SkipPaths are not recorded, path ends with * is a prefix, like /metrics or /static/*
+	SkipPaths []string
+}
+
+// unmatchedRoute is the route label of requests which match no route,
+// raw paths are not used as labels to avoid high cardinality.
+const unmatchedRoute = "<unmatched>"
+
+// Prometheus returns a nice middleware records metrics of requests by prometheus
+func Prometheus() nice.HandlerFunc {
+	return PrometheusWithConfig(PrometheusConfig{})
+}
+
+// PrometheusWithConfig returns a nice middleware records requests_total, request_duration_seconds
+// and response_size_bytes labeled by method, route and status, and requests_in_flight.
+// route is the route name or pattern, not the raw path.
+//
+// Example:
+//	app.Use(middleware.PrometheusWithConfig(middleware.PrometheusConfig{SkipPaths: []string{"/metrics"}}))
+//	app.Get("/metrics", middleware.MetricsHandler(nil))
+func PrometheusWithConfig(config PrometheusConfig) nice.HandlerFunc {
+	if config.Namespace == "" {
+		config.Namespace = "nice"
+	}
+	if config.Subsystem == "" {
+		config.Subsystem = "http"
+	}
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+	if config.SizeBuckets == nil {
+		config.SizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+	}
+	labels := []string{"method", "route", "status"}
+
+	requests := registerCollector(config.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests.",
+	}, labels)).(*prometheus.CounterVec)
+	duration := registerCollector(config.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests in seconds.",
+		Buckets:   config.Buckets,
+	}, labels)).(*prometheus.HistogramVec)
+	size := registerCollector(config.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "response_size_bytes",
+		Help:      "Size of HTTP responses in bytes.",
+		Buckets:   config.SizeBuckets,
+	}, labels)).(*prometheus.HistogramVec)
+	inFlight := registerCollector(config.Registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests being served.",
+	})).(prometheus.Gauge)
+
+	return func(c *nice.Context) {
+		path := c.Req.URL.Path
+		for _, p := range config.SkipPaths {
+			if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, p[:len(p)-1])) {
+				c.Next()
+				return
+			}
+		}
+
+		start := time.Now()
+		inFlight.Inc()
+		defer func() {
+			inFlight.Dec()
+			route := logRoute(c)
+			if route == "" {
+				route = unmatchedRoute
+			}
+			values := []string{metricsMethod(c.Req.Method), route, strconv.Itoa(c.Resp.Status())}
+			requests.WithLabelValues(values...).Inc()
+			duration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+			size.WithLabelValues(values...).Observe(float64(c.Resp.Size()))
+		}()
+
+		c.Next()
+	}
+}
+
+// metricsMethod returns the method label, unknown methods are OTHER
+func metricsMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE":
+		return method
+	}
+	return "OTHER"
+}
+
+// registerCollector registers collector, the registered one is returned
+// if it is registered already, so the middleware could be created again.
+func registerCollector(r prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	if err := r.Register(collector); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
+
+// MetricsHandler returns a nice handler serves metrics of gatherer,
+// default is prometheus.DefaultGatherer.
+func MetricsHandler(gatherer prometheus.Gatherer) nice.HandlerFunc {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	h := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+	return func(c *nice.Context) {
+		h.ServeHTTP(c.Resp, c.Req)
+	}
+}
+
+// poolCollector collects stats of db and cache pools of the application
+type poolCollector struct {
+	app *nice.Nice
+
+	dbOpen, dbInUse, dbIdle, dbMaxOpen, dbWaitCount, dbWaitDuration *prometheus.Desc
+	cacheActive, cacheIdle, cacheWaitCount, cacheWaitDuration       *prometheus.Desc
+}
+
+// NewPoolCollector returns a collector of db and cache pools registered in DI of app,
+// db should implement nice.DbStats and cache should implement nice.CacheStats,
+// like nice.Mysql and nice.Redis. stats are read when metrics are gathered.
+//
+// Example:
+//	prometheus.MustRegister(middleware.NewPoolCollector(app, "nice"))
+func NewPoolCollector(app *nice.Nice, namespace string) prometheus.Collector {
+	desc := func(subsystem, name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
+	}
+	return &poolCollector{
+		app:               app,
+		dbOpen:            desc("db", "open_connections", "Number of established db connections, in use and idle.", "pool"),
+		dbInUse:           desc("db", "in_use_connections", "Number of db connections in use.", "pool"),
+		dbIdle:            desc("db", "idle_connections", "Number of idle db connections.", "pool"),
+		dbMaxOpen:         desc("db", "max_open_connections", "Maximum number of open db connections.", "pool"),
+		dbWaitCount:       desc("db", "wait_count_total", "Total number of db connections waited for.", "pool"),
+		dbWaitDuration:    desc("db", "wait_duration_seconds_total", "Total time waited for db connections.", "pool"),
+		cacheActive:       desc("cache", "active_connections", "Number of cache connections, in use and idle."),
+		cacheIdle:         desc("cache", "idle_connections", "Number of idle cache connections."),
+		cacheWaitCount:    desc("cache", "wait_count_total", "Total number of cache connections waited for."),
+		cacheWaitDuration: desc("cache", "wait_duration_seconds_total", "Total time waited for cache connections."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (pc *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		pc.dbOpen, pc.dbInUse, pc.dbIdle, pc.dbMaxOpen, pc.dbWaitCount, pc.dbWaitDuration,
+		pc.cacheActive, pc.cacheIdle, pc.cacheWaitCount, pc.cacheWaitDuration,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (pc *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	if db, ok := pc.app.Db().(nice.DbStats); ok {
+		for pool, s := range db.Stats() {
+			ch <- prometheus.MustNewConstMetric(pc.dbOpen, prometheus.GaugeValue, float64(s.OpenConnections), pool)
+			ch <- prometheus.MustNewConstMetric(pc.dbInUse, prometheus.GaugeValue, float64(s.InUse), pool)
+			ch <- prometheus.MustNewConstMetric(pc.dbIdle, prometheus.GaugeValue, float64(s.Idle), pool)
+			ch <- prometheus.MustNewConstMetric(pc.dbMaxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), pool)
+			ch <- prometheus.MustNewConstMetric(pc.dbWaitCount, prometheus.CounterValue, float64(s.WaitCount), pool)
+			ch <- prometheus.MustNewConstMetric(pc.dbWaitDuration, prometheus.CounterValue, s.WaitDuration.Seconds(), pool)
+		}
+	}
+	if cache, ok := pc.app.Cache().(nice.CacheStats); ok {
+		s := cache.Stats()
+		ch <- prometheus.MustNewConstMetric(pc.cacheActive, prometheus.GaugeValue, float64(s.ActiveCount))
+		ch <- prometheus.MustNewConstMetric(pc.cacheIdle, prometheus.GaugeValue, float64(s.IdleCount))
+		ch <- prometheus.MustNewConstMetric(pc.cacheWaitCount, prometheus.CounterValue, float64(s.WaitCount))
+		ch <- prometheus.MustNewConstMetric(pc.cacheWaitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+	}
+}
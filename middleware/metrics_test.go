@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nice "../"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// metricsValue returns value of the metric with labels, -1 if not found
+func metricsValue(g prometheus.Gatherer, name string, labels map[string]string) float64 {
+	mfs, _ := g.Gather()
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if !metricsLabels(m, labels) {
+				continue
+			}
+			switch {
+			case m.Counter != nil:
+				return m.Counter.GetValue()
+			case m.Gauge != nil:
+				return m.Gauge.GetValue()
+			case m.Histogram != nil:
+				return float64(m.Histogram.GetSampleCount())
+			}
+		}
+	}
+	return -1
+}
+
+// metricsLabels returns whether the metric has labels
+func metricsLabels(m *dto.Metric, labels map[string]string) bool {
+	n := 0
+	for _, lp := range m.GetLabel() {
+		v, ok := labels[lp.GetName()]
+		if !ok || v != lp.GetValue() {
+			return false
+		}
+		n++
+	}
+	return n == len(labels)
+}
+
+func TestPrometheus1(t *testing.T) {
+	Convey("metrics of requests", t, func() {
+		reg := prometheus.NewRegistry()
+		var inFlight float64
+		app := nice.New()
+		app.Use(PrometheusWithConfig(PrometheusConfig{Registerer: reg, SkipPaths: []string{"/metrics", "/static/*"}}))
+		app.Get("/users/:id", func(c *nice.Context) {
+			inFlight = metricsValue(reg, "nice_http_requests_in_flight", nil)
+			c.String(http.StatusOK, "user")
+		}).Name("user")
+		app.Post("/users/:id", func(c *nice.Context) {
+			c.String(http.StatusCreated, "created")
+		})
+		app.Get("/static/*", func(c *nice.Context) {
+			c.String(http.StatusOK, "static")
+		})
+		app.Get("/metrics", MetricsHandler(reg))
+		serve := func(method, path string) {
+			req := httptest.NewRequest(method, path, nil)
+			app.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		serve("GET", "/users/1")
+		serve("GET", "/users/2")
+		serve("POST", "/users/1")
+		serve("GET", "/none/1")
+		serve("PROPFIND", "/none/2")
+		serve("GET", "/static/a.css")
+		serve("GET", "/metrics")
+
+		So(metricsValue(reg, "nice_http_requests_total",
+			map[string]string{"method": "GET", "route": "user", "status": "200"}), ShouldEqual, 2)
+		So(metricsValue(reg, "nice_http_requests_total",
+			map[string]string{"method": "POST", "route": "/users/:id", "status": "201"}), ShouldEqual, 1)
+		So(metricsValue(reg, "nice_http_requests_total",
+			map[string]string{"method": "GET", "route": "<unmatched>", "status": "404"}), ShouldEqual, 1)
+		So(metricsValue(reg, "nice_http_requests_total",
+			map[string]string{"method": "OTHER", "route": "<unmatched>", "status": "404"}), ShouldEqual, 1)
+		So(metricsValue(reg, "nice_http_request_duration_seconds",
+			map[string]string{"method": "GET", "route": "user", "status": "200"}), ShouldEqual, 2)
+		So(metricsValue(reg, "nice_http_response_size_bytes",
+			map[string]string{"method": "GET", "route": "user", "status": "200"}), ShouldEqual, 2)
+
+		// skip paths
+		mfs, _ := reg.Gather()
+		for _, mf := range mfs {
+			for _, m := range mf.GetMetric() {
+				for _, lp := range m.GetLabel() {
+					So(lp.GetValue(), ShouldNotEqual, "/static/*")
+					So(lp.GetValue(), ShouldNotEqual, "/metrics")
+				}
+			}
+		}
+
+		// in flight
+		So(inFlight, ShouldEqual, 1)
+		So(metricsValue(reg, "nice_http_requests_in_flight", nil), ShouldEqual, 0)
+
+		// created again with the registered metrics
+		So(func() { PrometheusWithConfig(PrometheusConfig{Registerer: reg}) }, ShouldNotPanic)
+	})
+}
@@ -14,6 +14,11 @@ func Recovery() nice.HandlerFunc {
 	return func(c *nice.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// raised HTTPError is a normal response, not a crash
+				if he, ok := err.(*nice.HTTPError); ok {
+					c.Error(he)
+					return
+				}
 				trace := make([]byte, 1<<16)
 				n := runtime.Stack(trace, true)
 				c.Error(fmt.Errorf("panic recover\n %v\n stack trace %d bytes\n %s", err, n, trace[:n]))
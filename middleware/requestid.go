@@ -0,0 +1,66 @@
+// Package requestid provider a nice middleware for request id.
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	nice "../"
+)
+
+// RequestIDConfig defines the config of RequestID middleware
+type RequestIDConfig struct {
+	// Header is the header of request id, default is X-Request-ID
+	Header string
+	// Generator creates id for requests without one, default is 32 hex chars
+	Generator func() string
+	// IgnoreIncoming always creates a new id, the id from client is not trusted
+	IgnoreIncoming bool
+}
+
+// RequestID returns a nice middleware which honors X-Request-ID of request or
+// creates one, the id is stored on context, see nice.Context.RequestID, and
+// echoed in response. it should be used before Logger and Recovery.
+func RequestID() nice.HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig returns a RequestID middleware with config
+func RequestIDWithConfig(config RequestIDConfig) nice.HandlerFunc {
+	if config.Header == "" {
+		config.Header = nice.HeaderXRequestID
+	}
+	if config.Generator == nil {
+		config.Generator = newRequestID
+	}
+	return func(c *nice.Context) {
+		var id string
+		if !config.IgnoreIncoming {
+			id = c.Req.Header.Get(config.Header)
+		}
+		if !nice.ValidRequestID(id) {
+			id = config.Generator()
+		}
+		c.SetRequestID(id)
+		if config.Header != nice.HeaderXRequestID {
+			c.Resp.Header().Set(config.Header, id)
+		}
+
+		c.Next()
+	}
+}
+
+// requestIDSeq makes ids unique when random source fails
+var requestIDSeq uint64
+
+// newRequestID returns 16 random bytes in hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x-%x", time.Now().UnixNano(), atomic.AddUint64(&requestIDSeq, 1))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,99 @@
+// Package tracing provider a nice middleware for OpenTelemetry tracing.
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	nice "../"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConfig defines the config of Tracing middleware
+type TracingConfig struct {
+	// TracerProvider creates the tracer, default is the global tracer provider
+	TracerProvider trace.TracerProvider
+	// Propagator extracts the parent span from request, default is W3C traceparent and baggage
+	Propagator propagation.TextMapPropagator
+	// SkipPaths are not traced, path ends with * is a prefix, like /health or /static/*
+	SkipPaths []string
+}
+
+// Tracing returns a nice middleware starts a server span for each request
+func Tracing() nice.HandlerFunc {
+	return TracingWithConfig(TracingConfig{})
+}
+
+// TracingWithConfig returns a nice middleware starts a server span for each request,
+// the span is named by method and route pattern, like "GET /users/:id", and the
+// parent is extracted from traceparent header. c.Ctx() carries the span, so calls
+// of Mysql, Redis and gRPC with it are traced as children.
+//
+// Example:
+//	tp := tracing.Init("user", exporter)
+//	app.Use(middleware.Tracing())
+//	app.Get("/users/:id", func(c *nice.Context) {
+//		user, err := db.QueryContext(c.Ctx(), "SELECT * FROM user WHERE id = ?", c.Param("id"))
+//	})
+func TracingWithConfig(config TracingConfig) nice.HandlerFunc {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = nice.TracePropagator()
+	}
+	tracer := config.TracerProvider.Tracer("github.com/nic-chen/nice/middleware")
+
+	return func(c *nice.Context) {
+		path := c.Req.URL.Path
+		for _, p := range config.SkipPaths {
+			if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, p[:len(p)-1])) {
+				c.Next()
+				return
+			}
+		}
+
+		name := c.Req.Method
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", c.Req.Method),
+			attribute.String("url.path", path),
+			attribute.String("server.address", c.Req.Host),
+			attribute.String("client.address", c.RemoteAddr()),
+			attribute.String("user_agent.original", c.Req.UserAgent()),
+		}
+		if route := c.RoutePattern(); route != "" {
+			name += " " + route
+			attrs = append(attrs, attribute.String("http.route", route))
+		}
+		if id := c.RequestID(); id != "" {
+			attrs = append(attrs, attribute.String("http.request.id", id))
+		}
+
+		ctx := config.Propagator.Extract(c.Ctx(), propagation.HeaderCarrier(c.Req.Header))
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		c.WithContext(ctx)
+		defer func() {
+			if p := recover(); p != nil {
+				span.SetStatus(codes.Error, fmt.Sprint(p))
+				span.End()
+				panic(p)
+			}
+			status := c.Resp.Status()
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if err := c.Err(); err != nil {
+				span.RecordError(err)
+			}
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			span.End()
+		}()
+
+		c.Next()
+	}
+}
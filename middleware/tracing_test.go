@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nice "../"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing1(t *testing.T) {
+	Convey("server span of request", t, func() {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+		app := nice.New()
+		app.Use(TracingWithConfig(TracingConfig{TracerProvider: tp, SkipPaths: []string{"/health"}}))
+		app.Get("/users/:id", func(c *nice.Context) {
+			_, span := nice.Tracer(c.Ctx()).Start(c.Ctx(), "handler")
+			span.End()
+			c.String(http.StatusOK, c.Param("id"))
+		})
+		app.Get("/fail", func(c *nice.Context) {
+			c.String(http.StatusInternalServerError, "fail")
+		})
+		app.Get("/health", func(c *nice.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		serve := func(path string, header http.Header) {
+			req := httptest.NewRequest("GET", path, nil)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		Convey("named by route pattern with parent of traceparent", func() {
+			serve("/users/1", http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}})
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 2)
+			child, server := spans[0], spans[1]
+			So(server.Name, ShouldEqual, "GET /users/:id")
+			So(server.SpanKind, ShouldEqual, trace.SpanKindServer)
+			So(server.SpanContext.TraceID().String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+			So(server.Parent.SpanID().String(), ShouldEqual, "00f067aa0ba902b7")
+			So(server.Parent.IsRemote(), ShouldBeTrue)
+			So(server.Attributes, ShouldContain, attribute.String("http.route", "/users/:id"))
+			So(server.Attributes, ShouldContain, attribute.Int("http.response.status_code", http.StatusOK))
+			So(server.Status.Code, ShouldEqual, codes.Unset)
+			So(child.Name, ShouldEqual, "handler")
+			So(child.Parent.SpanID(), ShouldEqual, server.SpanContext.SpanID())
+		})
+		Convey("root span without traceparent", func() {
+			serve("/users/1", nil)
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 2)
+			So(spans[1].Parent.IsValid(), ShouldBeFalse)
+		})
+		Convey("error status", func() {
+			serve("/fail", nil)
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name, ShouldEqual, "GET /fail")
+			So(spans[0].Status.Code, ShouldEqual, codes.Error)
+		})
+		Convey("skip paths", func() {
+			serve("/health", nil)
+			So(exporter.GetSpans(), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package nice
+
+import (
+	"strings"
+)
+
+// mount is a sub application mounted under prefix
+type mount struct {
+	prefix   string
+	nice     *Nice // parent application
+	sub      *Nice
+	handlers []HandlerFunc // handlers of mount routes, with group handlers
+	methods  []string      // methods of mount routes
+}
+
+// Mount mounts the sub application under prefix, requests of the prefix are
+// served by sub with its own middleware, DI and error handler after the
+// middleware of n. sub sees the path stripped prefix, and writes the same Response.
+//
+// Example:
+//	billing := nice.New()
+//	billing.Get("/invoices/:id", h).Name("invoice")
+//	app.Mount("/billing", billing)
+//	app.URLFor("invoice", 1) // /billing/invoices/1
+func (n *Nice) Mount(prefix string, sub *Nice) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		panic("nice.Mount prefix can not be empty or /")
+	}
+	if sub == nil || sub == n {
+		panic("nice.Mount sub application is invalid")
+	}
+	if sub.parent != nil {
+		panic("nice.Mount sub application has been mounted")
+	}
+
+	m := &mount{nice: n, sub: sub}
+	h := func(c *Context) {
+		m.serve(c)
+	}
+	// common methods and custom methods of the router
+	for method := range RouterMethods {
+		m.methods = append(m.methods, method)
+	}
+	if t, ok := n.Router().(*Tree); ok {
+		m.methods = append(m.methods, t.customMethods()...)
+	}
+	var node *Node
+	for _, method := range m.methods {
+		ru := n.Router().Add(method, prefix, []HandlerFunc{h}).Meta(MetaHidden, true)
+		n.Router().Add(method, prefix+"/*", []HandlerFunc{h}).Meta(MetaHidden, true)
+		node, _ = ru.(*Node)
+	}
+
+	// prefix with group pattern
+	m.prefix = node.Pattern()
+	m.handlers = node.load().handlers
+	n.mounts = append(n.mounts, m)
+	sub.parent = m
+}
+
+// route registers routes of the mount to r with the methods registered
+// by Mount, it is used to keep mounted applications in the new route table of SwapRoutes.
+func (m *mount) route(r Router) {
+	for _, method := range m.methods {
+		r.Add(method, m.prefix, m.handlers).Meta(MetaHidden, true)
+		r.Add(method, m.prefix+"/*", m.handlers).Meta(MetaHidden, true)
+	}
+}
+
+// serve serves the request by sub application with stripped path
+func (m *mount) serve(c *Context) {
+	r := c.Req.WithContext(c.Ctx())
+	u := *r.URL
+	u.Path = "/" + c.Param("")
+	u.RawPath = ""
+	r.URL = &u
+
+	sc := m.sub.pool.Get().(*Context)
+	sc.Reset(c.Resp, r)
+	resp := sc.Resp
+	sc.Resp = c.Resp
+	m.sub.serve(sc)
+	sc.Resp = resp
+	m.sub.pool.Put(sc)
+}
+
+// mountPrefix returns the full prefix of application mounted, empty if not mounted
+func (n *Nice) mountPrefix() string {
+	if n.parent == nil {
+		return ""
+	}
+	return n.parent.nice.mountPrefix() + n.parent.prefix
+}
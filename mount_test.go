@@ -0,0 +1,113 @@
+package nice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMount1(t *testing.T) {
+	Convey("mount sub application", t, func() {
+		var order []string
+		app := New()
+		app.Use(func(c *Context) {
+			order = append(order, "app")
+			c.Next()
+		})
+		app.Get("/", func(c *Context) {
+			c.String(200, c.Nice().URLFor("invoice", 7))
+		}).Name("home")
+
+		billing := New()
+		billing.SetDI("name", "billing")
+		billing.Use(func(c *Context) {
+			order = append(order, "billing")
+			c.Next()
+		})
+		billing.SetError(func(err error, c *Context) {
+			c.String(http.StatusTeapot, "billing: "+err.Error())
+		})
+		billing.Get("/", func(c *Context) {
+			c.String(200, "billing index "+c.Req.URL.Path)
+		})
+		billing.Get("/invoices/:id", func(c *Context) {
+			c.String(200, fmt.Sprintf("%s %s %s", c.Nice().GetDI("name"), c.Req.URL.Path, c.Param("id")))
+		}).Name("invoice")
+		billing.Get("/error", HandlerE(func(c *Context) error {
+			return fmt.Errorf("BOMB")
+		}))
+		billing.Get("/home", func(c *Context) {
+			c.String(200, c.Nice().URLFor("home")+" "+c.Nice().URLFor("invoice", 1))
+		})
+		billing.AddMethod("PROPFIND")
+		billing.Route("/dav", "PROPFIND", func(c *Context) {
+			c.String(207, "dav")
+		})
+		app.AddMethod("PROPFIND")
+		app.Group("/v1", func() {
+			app.Mount("/billing/", billing)
+		})
+
+		Convey("serve mounted", func() {
+			w := appRequest(app, "GET", "/v1/billing/invoices/1?x=1")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, "billing /invoices/1 1")
+			So(order, ShouldResemble, []string{"app", "billing"})
+
+			w = appRequest(app, "GET", "/v1/billing")
+			So(w.Body.String(), ShouldEqual, "billing index /")
+
+			w = appRequest(app, "GET", "/v1/billing/error")
+			So(w.Code, ShouldEqual, http.StatusTeapot)
+			So(w.Body.String(), ShouldEqual, "billing: BOMB")
+
+			w = appRequest(app, "PROPFIND", "/v1/billing/dav")
+			So(w.Body.String(), ShouldEqual, "dav")
+
+			w = appRequest(app, "GET", "/v1/billing/none")
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("url for across mounts", func() {
+			So(app.URLFor("invoice", 3), ShouldEqual, "/v1/billing/invoices/3")
+			w := appRequest(app, "GET", "/")
+			So(w.Body.String(), ShouldEqual, "/v1/billing/invoices/7")
+			w = appRequest(app, "GET", "/v1/billing/home")
+			So(w.Body.String(), ShouldEqual, "/ /v1/billing/invoices/1")
+		})
+		Convey("mounted kept by swap routes", func() {
+			app.SwapRoutes(func() {
+				app.Get("/", f)
+			})
+			w := appRequest(app, "GET", "/v1/billing/invoices/2")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, "billing /invoices/2 2")
+			So(app.URLFor("invoice", 3), ShouldEqual, "/v1/billing/invoices/3")
+			w = appRequest(app, "PROPFIND", "/v1/billing/dav")
+			So(w.Code, ShouldEqual, 207)
+
+			other := New()
+			So(func() {
+				app.SwapRoutes(func() {
+					app.Mount("/other", other)
+					panic("failed")
+				})
+			}, ShouldPanic)
+			So(len(app.mounts), ShouldEqual, 1)
+			So(func() { app.Mount("/other", other) }, ShouldNotPanic)
+		})
+		Convey("mount invalid", func() {
+			So(func() { app.Mount("/", New()) }, ShouldPanic)
+			So(func() { app.Mount("/again", billing) }, ShouldPanic)
+		})
+	})
+}
+
+func appRequest(b *Nice, method, uri string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, uri, nil)
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, req)
+	return w
+}
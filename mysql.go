@@ -1,11 +1,12 @@
 package nice
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/otel/attribute"
 	"log"
 	"os"
 	"strconv"
@@ -22,23 +23,32 @@ type Mysql struct {
 
 type MysqlConf struct {
 	//map类型
-	Master      MysqlNode `yaml: "master"`
-	Slave       MysqlNode `yaml: "slave"`
-	Database    string    `yaml: "database"`
-	Charset     string    `yaml: "charset"`
-	MaxLifetime string    `yaml: "maxlifetime"`
+	Master      MysqlNode `yaml:"master"`
+	Slave       MysqlNode `yaml:"slave"`
+	Database    string    `yaml:"database"`
+	Charset     string    `yaml:"charset" default:"utf8mb4"`
+	MaxLifetime string    `yaml:"maxlifetime"`
 }
 
 type MysqlNode struct {
-	Host     string `yaml: "host"`
-	User     string `yaml: "user"`
-	Password string `yaml: "password"`
-	MaxOpen  int    `yaml: "maxopen"` //maxOpenConn
-	MaxIdle  int    `yaml: "maxidle"` //maxIdleConn
+	Host     string `yaml:"host"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	MaxOpen  int    `yaml:"maxopen"` //maxOpenConn
+	MaxIdle  int    `yaml:"maxidle"` //maxIdleConn
 }
 
-// Init DB pool
+// Init DB pool, config could be MysqlConf, *MysqlConf or map, panics if failed
 func NewMysql(config interface{}) *Mysql {
+	p, err := OpenMysql(config)
+	if err != nil {
+		log.Panicln("Init mysql pool failed.", err.Error())
+	}
+	return p
+}
+
+// OpenMysql create DB pool, config could be MysqlConf, *MysqlConf or map
+func OpenMysql(config interface{}) (*Mysql, error) {
 	var err error
 	p := &Mysql{
 		DriverName: "mysql",
@@ -46,23 +56,26 @@ func NewMysql(config interface{}) *Mysql {
 	}
 
 	conf := MysqlConf{}
-	err = mapstructure.Decode(config.(map[interface{}]interface{}), &conf)
+	if err = decodeConf(config, &conf); err != nil {
+		return nil, err
+	}
 
 	p.Master, err = p.Connect(conf.Master, conf.Database, conf.Charset, conf.MaxLifetime)
 	if err != nil {
-		p.Loger.Panicln("Init mysql master pool failed.", err.Error())
+		return nil, fmt.Errorf("master: %v", err)
 	}
 
 	if conf.Slave.Host != "" {
 		p.Slave, err = p.Connect(conf.Slave, conf.Database, conf.Charset, conf.MaxLifetime)
 		if err != nil {
-			return nil
+			p.Master.Close()
+			return nil, fmt.Errorf("slave: %v", err)
 		}
 	} else {
 		p.Slave = p.Master
 	}
 
-	return p
+	return p, nil
 }
 
 func (p *Mysql) Connect(node MysqlNode, database, charset, MaxLifetime string) (*sql.DB, error) {
@@ -88,9 +101,27 @@ func (p *Mysql) Connect(node MysqlNode, database, charset, MaxLifetime string) (
 	return conn, err
 }
 
+// Stats returns stats of master and slave pools, slave is omitted if it is master,
+// pools not connected are omitted.
+func (p *Mysql) Stats() map[string]sql.DBStats {
+	stats := make(map[string]sql.DBStats)
+	if p.Master != nil {
+		stats["master"] = p.Master.Stats()
+	}
+	if p.Slave != nil && p.Slave != p.Master {
+		stats["slave"] = p.Slave.Stats()
+	}
+	return stats
+}
+
 // Close pool
 func (p *Mysql) Close() error {
-	p.Slave.Close()
+	if p.Slave != nil && p.Slave != p.Master {
+		p.Slave.Close()
+	}
+	if p.Master == nil {
+		return nil
+	}
 	return p.Master.Close()
 }
 
@@ -109,9 +140,17 @@ func (p *Mysql) Get(queryStr string, args ...interface{}) (map[string]interface{
 	return results[0], nil
 }
 
-// Query via pool
+// Query via pool, it is traced as a root span
 func (p *Mysql) Query(sqlStr string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := p.Slave.Query(sqlStr, args...)
+	return p.QueryContext(context.Background(), sqlStr, args...)
+}
+
+// QueryContext via pool with context
+func (p *Mysql) QueryContext(ctx context.Context, sqlStr string, args ...interface{}) (_ []map[string]interface{}, err error) {
+	ctx, span := startSpan(ctx, "mysql.query", mysqlAttrs("query", sqlStr)...)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := p.Slave.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		p.Loger.Printf("query err: %v sql: %s", err, sqlStr)
 		return []map[string]interface{}{}, err
@@ -139,13 +178,31 @@ func (p *Mysql) Query(sqlStr string, args ...interface{}) ([]map[string]interfac
 	return rowsMap, nil
 }
 
-// QueryRow via pool
+// QueryRow via pool, it is traced as a root span
 func (p *Mysql) QueryRow(sqlStr string, args ...interface{}) *sql.Row {
-	return p.Slave.QueryRow(sqlStr, args...)
+	return p.QueryRowContext(context.Background(), sqlStr, args...)
+}
+
+// QueryRowContext via pool with context, the span ends when the row is returned,
+// so it covers the query but not Scan, and errors returned by Scan, like
+// sql.ErrNoRows, are not recorded in the span.
+func (p *Mysql) QueryRowContext(ctx context.Context, sqlStr string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(ctx, "mysql.query", mysqlAttrs("query", sqlStr)...)
+	row := p.Slave.QueryRowContext(ctx, sqlStr, args...)
+	endSpan(span, row.Err())
+	return row
 }
 
+// Exec via pool, it is traced as a root span
 func (p *Mysql) Exec(sqlStr string, args ...interface{}) (sql.Result, error) {
-	res, err := p.Master.Exec(sqlStr, args...)
+	return p.ExecContext(context.Background(), sqlStr, args...)
+}
+
+// ExecContext via pool with context
+func (p *Mysql) ExecContext(ctx context.Context, sqlStr string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(ctx, "mysql.exec", mysqlAttrs("exec", sqlStr)...)
+	res, err := p.Master.ExecContext(ctx, sqlStr, args...)
+	endSpan(span, err)
 	if err != nil {
 		p.Loger.Printf("exec err: %v sql: %s", err, sqlStr)
 	}
@@ -191,10 +248,16 @@ type SQLConnTransaction struct {
 
 // Begin transaction
 func (p *Mysql) Begin() (*SQLConnTransaction, error) {
+	return p.BeginContext(context.Background())
+}
+
+// BeginContext transaction with context, the transaction will be rolled back
+// if the context is canceled before commit
+func (p *Mysql) BeginContext(ctx context.Context) (*SQLConnTransaction, error) {
 	var oneSQLConnTransaction = &SQLConnTransaction{}
 	var err error
-	if pingErr := p.Master.Ping(); pingErr == nil {
-		oneSQLConnTransaction.SQLTX, err = p.Master.Begin()
+	if pingErr := p.Master.PingContext(ctx); pingErr == nil {
+		oneSQLConnTransaction.SQLTX, err = p.Master.BeginTx(ctx, nil)
 		oneSQLConnTransaction.Loger = p.Loger
 	}
 	return oneSQLConnTransaction, err
@@ -210,6 +273,22 @@ func (t *SQLConnTransaction) Commit() error {
 	return t.SQLTX.Commit()
 }
 
+// CloseWithError commits the transaction if err is nil, otherwise rolls back,
+// it is called when the request scope of DI is closed.
+func (t *SQLConnTransaction) CloseWithError(err error) error {
+	if err != nil {
+		rerr := t.SQLTX.Rollback()
+		if rerr == sql.ErrTxDone {
+			return nil
+		}
+		return rerr
+	}
+	if cerr := t.SQLTX.Commit(); cerr != sql.ErrTxDone {
+		return cerr
+	}
+	return nil
+}
+
 // Get via transaction
 func (t *SQLConnTransaction) Get(queryStr string, args ...interface{}) (map[string]interface{}, error) {
 	results, err := t.Query(queryStr, args...)
@@ -227,7 +306,15 @@ func (t *SQLConnTransaction) Get(queryStr string, args ...interface{}) (map[stri
 
 // Query via transaction
 func (t *SQLConnTransaction) Query(queryStr string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := t.SQLTX.Query(queryStr, args...)
+	return t.QueryContext(context.Background(), queryStr, args...)
+}
+
+// QueryContext via transaction with context
+func (t *SQLConnTransaction) QueryContext(ctx context.Context, queryStr string, args ...interface{}) (_ []map[string]interface{}, err error) {
+	ctx, span := startSpan(ctx, "mysql.query", mysqlAttrs("query", queryStr)...)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := t.SQLTX.QueryContext(ctx, queryStr, args...)
 	if err != nil {
 		t.Loger.Printf("t query err: %v", err)
 		return []map[string]interface{}{}, err
@@ -255,7 +342,14 @@ func (t *SQLConnTransaction) Query(queryStr string, args ...interface{}) ([]map[
 }
 
 func (t *SQLConnTransaction) Exec(sqlStr string, args ...interface{}) (sql.Result, error) {
-	res, err := t.SQLTX.Exec(sqlStr, args...)
+	return t.ExecContext(context.Background(), sqlStr, args...)
+}
+
+// ExecContext via transaction with context
+func (t *SQLConnTransaction) ExecContext(ctx context.Context, sqlStr string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(ctx, "mysql.exec", mysqlAttrs("exec", sqlStr)...)
+	res, err := t.SQLTX.ExecContext(ctx, sqlStr, args...)
+	endSpan(span, err)
 	if err != nil {
 		t.Loger.Printf("t exec err: %v", err)
 	}
@@ -293,6 +387,15 @@ func (t *SQLConnTransaction) Delete(deleteStr string, args ...interface{}) (int6
 	return affect, err
 }
 
+// mysqlAttrs returns attributes of mysql span
+func mysqlAttrs(operation, sqlStr string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("db.system.name", "mysql"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", sqlStr),
+	}
+}
+
 // bytes2RealType is to convert db type to code type
 func bytes2RealType(src []byte, column *sql.ColumnType) interface{} {
 	srcStr := string(src)
@@ -0,0 +1,39 @@
+package nice
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMysqlStats1(t *testing.T) {
+	Convey("stats of db pools", t, func() {
+		master, err := sql.Open("mysql", "root:@tcp(127.0.0.1:0)/nice")
+		So(err, ShouldBeNil)
+		master.SetMaxOpenConns(5)
+		slave, _ := sql.Open("mysql", "root:@tcp(127.0.0.1:0)/nice")
+		slave.SetMaxOpenConns(3)
+
+		Convey("master only", func() {
+			b2 := New()
+			b2.SetDI("db", &Mysql{Master: master, Slave: master})
+			stats := b2.Db().(DbStats).Stats()
+			So(stats, ShouldHaveLength, 1)
+			So(stats["master"].MaxOpenConnections, ShouldEqual, 5)
+			So(b2.di.(*DI).Close(), ShouldBeNil)
+		})
+		Convey("master and slave", func() {
+			p := &Mysql{Master: master, Slave: slave}
+			stats := p.Stats()
+			So(stats, ShouldHaveLength, 2)
+			So(stats["slave"].MaxOpenConnections, ShouldEqual, 3)
+			So(p.Close(), ShouldBeNil)
+		})
+		Convey("not connected", func() {
+			p := &Mysql{}
+			So(p.Stats(), ShouldBeEmpty)
+			So(p.Close(), ShouldBeNil)
+		})
+	})
+}
@@ -1,14 +1,20 @@
 package nice
 
 import (
+	"context"
 	"errors"
-	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -28,12 +34,30 @@ type Nice struct {
 	debug           bool
 	name            string
 	Conf            map[string]interface{}
+	config          *Config
+	configOnce      sync.Once
 	di              DIer
-	router          Router
+	router          atomic.Value // routerBox of live router
+	building        atomic.Value // routerBox of router in registering by Host or SwapRoutes
+	hosts           []*host
+	mounts          []*mount
+	parent          *mount // mount point in parent application
+	host            *host // host in registering by Host
 	pool            sync.Pool
 	errorHandler    ErrorHandleFunc
 	notFoundHandler HandlerFunc
+	notAllowHandler HandlerFunc
+	autoOptions     bool
 	middleware      []HandlerFunc
+	servers         []*http.Server
+	serverMutex     sync.Mutex
+	stopped         bool          // Shutdown is called, no more servers
+	done            chan struct{} // closed when Shutdown finished
+	shutdownTimeout time.Duration
+	startHooks      []HookFunc
+	stopHooks       []HookFunc
+	startOnce       sync.Once
+	stopOnce        sync.Once
 }
 
 // Middleware middleware handler
@@ -42,19 +66,31 @@ type Middleware interface{}
 // HandlerFunc context handler func
 type HandlerFunc func(*Context)
 
+// HandlerFuncE context handler func returns error,
+// the returned error is handled by the error handler, see HandlerE
+type HandlerFuncE func(*Context) error
+
 // ErrorHandleFunc HTTP error handleFunc
 type ErrorHandleFunc func(error, *Context)
 
+// HookFunc application lifecycle hook
+type HookFunc func() error
+
 // appInstances storage application instances
 var appInstances map[string]*Nice
 
 // default application name
 const default_app_name = "nice"
 
+// default time to wait for in-flight requests when shutting down
+const defaultShutdownTimeout = 10 * time.Second
+
 // New create a nice application without any config.
 func New() *Nice {
 	n := new(Nice)
 	n.middleware = make([]HandlerFunc, 0)
+	n.shutdownTimeout = defaultShutdownTimeout
+	n.done = make(chan struct{})
 	n.pool = sync.Pool{
 		New: func() interface{} {
 			return NewContext(nil, nil, n)
@@ -70,6 +106,7 @@ func New() *Nice {
 	// n.SetDI("db", NewMysql())
 	// n.SetDI("cache", NewRedis())
 	n.SetNotFound(n.DefaultNotFoundHandler)
+	n.SetMethodNotAllowed(n.DefaultMethodNotAllowedHandler)
 	return n
 }
 
@@ -117,37 +154,244 @@ func (n *Nice) RunTLSServer(s *http.Server, crtFile, keyFile string) {
 }
 
 func (n *Nice) run(s *http.Server, files ...string) {
+	if len(files) != 0 && len(files) != 2 {
+		panic("invalid TLS configuration")
+	}
 	s.Handler = n
 	n.Logger().Printf("Run mode: %s", Env)
-	if len(files) == 0 {
-		n.Logger().Printf("Listen %s", s.Addr)
-		n.Logger().Fatal(s.ListenAndServe())
-	} else if len(files) == 2 {
-		n.Logger().Printf("Listen %s with TLS", s.Addr)
-		n.Logger().Fatal(s.ListenAndServeTLS(files[0], files[1]))
-	} else {
-		panic("invalid TLS configuration")
+	for _, w := range n.CheckRoutes() {
+		n.Logger().Printf("[WARN] route conflict: %s", w)
+	}
+	if err := n.start(); err != nil {
+		n.Logger().Fatal(err)
+	}
+	if !n.addServer(s) {
+		n.Logger().Printf("Application is shut down, %s is not listened", s.Addr)
+		<-n.done
+		return
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		if len(files) == 0 {
+			n.Logger().Printf("Listen %s", s.Addr)
+			errc <- s.ListenAndServe()
+		} else {
+			n.Logger().Printf("Listen %s with TLS", s.Addr)
+			errc <- s.ListenAndServeTLS(files[0], files[1])
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-errc:
+		// http.ErrServerClosed means Shutdown was called by someone else,
+		// wait it to finish stop hooks
+		if err != http.ErrServerClosed {
+			n.Logger().Fatal(err)
+		}
+		<-n.done
+	case sig := <-quit:
+		n.Logger().Printf("Receive signal %s, shutting down", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), n.shutdownTimeout)
+		defer cancel()
+		if err := n.Shutdown(ctx); err != nil {
+			n.Logger().Println("Shutdown error:", err)
+		}
+	}
+}
+
+// addServer tracks a running server for Shutdown, returns false if
+// the application is shut down.
+func (n *Nice) addServer(s *http.Server) bool {
+	n.serverMutex.Lock()
+	defer n.serverMutex.Unlock()
+	if n.stopped {
+		return false
+	}
+	n.servers = append(n.servers, s)
+	return true
+}
+
+// start execute start hooks once in registration order
+func (n *Nice) start() error {
+	var err error
+	n.startOnce.Do(func() {
+		for _, h := range n.startHooks {
+			if err = h(); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// OnStart registers hooks executed in order before the first server starts listening,
+// a hook returned error will stop the application.
+func (n *Nice) OnStart(h ...HookFunc) {
+	n.startHooks = append(n.startHooks, h...)
+}
+
+// OnStop registers hooks executed in reverse order when the application shutdown,
+// after in-flight requests are finished and before DI services are closed.
+func (n *Nice) OnStop(h ...HookFunc) {
+	n.stopHooks = append(n.stopHooks, h...)
+}
+
+// SetShutdownTimeout set the max time waiting for in-flight requests
+// when Run receives SIGINT or SIGTERM.
+func (n *Nice) SetShutdownTimeout(d time.Duration) {
+	n.shutdownTimeout = d
+}
+
+// Shutdown gracefully shuts down all running servers without interrupting
+// active connections, then executes stop hooks and closes DI services.
+// If ctx is done before requests finish, servers are closed and the error of ctx
+// is returned without closing DI services, which may be used by the running requests.
+// Only the first call takes effect, servers run after it are not listened.
+func (n *Nice) Shutdown(ctx context.Context) error {
+	var err error
+	n.stopOnce.Do(func() {
+		err = n.shutdown(ctx)
+		close(n.done)
+	})
+	return err
+}
+
+func (n *Nice) shutdown(ctx context.Context) error {
+	var errs []string
+	var timeout error
+
+	n.serverMutex.Lock()
+	servers := n.servers
+	n.servers = nil
+	n.stopped = true
+	n.serverMutex.Unlock()
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			s.Close()
+			timeout = err
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for i := len(n.stopHooks) - 1; i >= 0; i-- {
+		if err := n.stopHooks[i](); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if timeout != nil {
+		if len(errs) == 1 {
+			return timeout
+		}
+	} else if d, ok := n.di.(diCloser); ok {
+		if err := d.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
+	return nil
 }
 
 func (n *Nice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c := n.pool.Get().(*Context)
 	c.Reset(w, r)
+	n.serve(c)
+	n.pool.Put(c)
+}
+
+// serve matches route of the request then executes the handler chain
+func (n *Nice) serve(c *Context) {
+	r := c.Req
+
+	// match host route table
+	router, notFound := n.liveRouter(), n.notFoundHandler
+	if len(n.hosts) > 0 {
+		if ht := n.matchHost(c); ht != nil {
+			router = ht.router
+			if ht.notFound != nil {
+				notFound = ht.notFound
+			}
+		}
+	}
 
 	// build handler chain
-	h, name := n.Router().Match(r.Method, r.URL.Path, c)
+	h, name := router.Match(r.Method, r.URL.Path, c)
 	c.routeName = name
 
+	// route timeout
+	if c.route != nil && c.route.getTimeout() > 0 {
+		ctx, cancel := context.WithTimeout(c.Ctx(), c.route.getTimeout())
+		defer cancel()
+		c.WithContext(ctx)
+	}
+
 	// notFound
 	if h == nil {
-		c.handlers = append(c.handlers, n.notFoundHandler)
+		c.handlers = append(c.handlers, n.noRouteHandler(c, router, notFound))
 	} else {
 		c.handlers = append(c.handlers, h...)
 	}
 
+	// close request scope, rollback if panic
+	finished := false
+	defer func() {
+		if finished {
+			c.closeScope(c.Err())
+		} else {
+			c.closeScope(errHandlerPanic)
+		}
+	}()
 	c.Next()
+	finished = true
+}
 
-	n.pool.Put(c)
+// errHandlerPanic is the error closing request scope when handler panics
+var errHandlerPanic = errors.New("handler panic")
+
+// noRouteHandler returns handler for the request which has no route matched,
+// it is auto OPTIONS or method not allowed handler if the uri has route
+// under other methods, otherwise not found handler.
+func (n *Nice) noRouteHandler(c *Context, router Router, notFound HandlerFunc) HandlerFunc {
+	if n.notAllowHandler == nil && !n.autoOptions {
+		return notFound
+	}
+	methods := router.AllowedMethods(c.Req.URL.Path)
+	if len(methods) == 0 {
+		return notFound
+	}
+	if n.autoOptions && !hasMethod(methods, "OPTIONS") {
+		methods = append(methods, "OPTIONS")
+	}
+	allow := strings.Join(methods, ", ")
+	if n.autoOptions && c.Req.Method == "OPTIONS" {
+		return func(c *Context) {
+			c.Resp.Header().Set("Allow", allow)
+			c.Resp.WriteHeader(http.StatusNoContent)
+		}
+	}
+	if n.notAllowHandler == nil {
+		return notFound
+	}
+	c.Resp.Header().Set("Allow", allow)
+	return n.notAllowHandler
+}
+
+// hasMethod returns whether methods has method
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
 }
 
 // SetDIer set nice di
@@ -170,13 +414,26 @@ func (n *Nice) Logger() Logger {
 	return n.GetDI("logger").(Logger)
 }
 
-//db
+// Log returns the leveled logger of nice logger, see AsLevelLogger,
+// a logger which is not LevelLogger logs from debug level in debug mode, otherwise from info level.
+func (n *Nice) Log() LevelLogger {
+	level := InfoLevel
+	if n.debug {
+		level = DebugLevel
+	}
+	return AsLevelLogger(n.Logger(), level)
+}
+
+// Db returns db registered in DI, nil if not registered
 func (n *Nice) Db() Db {
-	return n.GetDI("db").(Db)
+	db, _ := n.GetDI("db").(Db)
+	return db
 }
 
+// Cache returns cache registered in DI, nil if not registered
 func (n *Nice) Cache() Cache {
-	return n.GetDI("cache").(Cache)
+	cache, _ := n.GetDI("cache").(Cache)
+	return cache
 }
 
 // Render return nice render
@@ -184,12 +441,88 @@ func (n *Nice) Render() Renderer {
 	return n.GetDI("render").(Renderer)
 }
 
-// Router return nice router
+// routerBox holds router in atomic.Value
+type routerBox struct {
+	Router
+}
+
+// Router return nice router, routes are registered to it.
+// it is the router of host in Host, the new router in SwapRoutes.
 func (n *Nice) Router() Router {
-	if n.router == nil {
-		n.router = n.GetDI("router").(Router)
+	if b, ok := n.building.Load().(routerBox); ok && b.Router != nil {
+		return b.Router
+	}
+	return n.liveRouter()
+}
+
+// liveRouter returns the router serving requests
+func (n *Nice) liveRouter() Router {
+	if b, ok := n.router.Load().(routerBox); ok {
+		return b.Router
+	}
+	r := n.GetDI("router").(Router)
+	n.router.Store(routerBox{r})
+	return r
+}
+
+// newRouter create a router inherits settings of the live router
+func (n *Nice) newRouter() Router {
+	t := NewTree(n)
+	if lt, ok := n.liveRouter().(*Tree); ok {
+		t.SetAutoHead(lt.autoHead)
+		t.SetAutoTrailingSlash(lt.autoTrailingSlash)
+		for _, m := range lt.customMethods() {
+			t.AddMethod(m)
+		}
 	}
-	return n.router
+	return t
+}
+
+// SwapRoutes builds a new route table by routes registered in f, then
+// replaces the live route table atomically, requests being served are not blocked.
+// routes of hosts and mounted applications are kept.
+//
+// Example:
+//	nice.SwapRoutes(func() {
+//		nice.Get("/", h)
+//		if featureOn {
+//			nice.Get("/beta", beta)
+//		}
+//	})
+func (n *Nice) SwapRoutes(f func()) {
+	if n.host != nil {
+		panic("nice.SwapRoutes can not be called in Host")
+	}
+	r := n.newRouter()
+	for _, m := range n.mounts {
+		m.route(r)
+	}
+	mounts := n.mounts
+	prev := n.building.Load()
+	n.building.Store(routerBox{r})
+	swapped := false
+	defer func() {
+		if prev == nil {
+			prev = routerBox{}
+		}
+		n.building.Store(prev)
+		if !swapped {
+			// applications mounted in f are dropped with the new table
+			for _, m := range n.mounts[len(mounts):] {
+				m.sub.parent = nil
+			}
+			n.mounts = mounts
+		}
+	}()
+	f()
+	n.SetDI("router", r)
+	swapped = true
+}
+
+// Remove removes the route registered with method and pattern from the live
+// route table, pattern includes group prefix. returns false if not found.
+func (n *Nice) Remove(method, pattern string) bool {
+	return n.liveRouter().Remove(method, pattern)
 }
 
 // Use registers a middleware
@@ -213,9 +546,11 @@ func (n *Nice) SetDI(name string, h interface{}) {
 			panic("DI render must be implement interface nice.Renderer")
 		}
 	case "router":
-		if _, ok := h.(Router); !ok {
+		r, ok := h.(Router)
+		if !ok {
 			panic("DI router must be implement interface nice.Router")
 		}
+		defer n.router.Store(routerBox{r})
 	}
 	n.di.Set(name, h)
 }
@@ -225,6 +560,66 @@ func (n *Nice) GetDI(name string) interface{} {
 	return n.di.Get(name)
 }
 
+// GetDIE fetch a di by name like GetDI, returns error if creating
+// the service provided by ProvideName failed
+func (n *Nice) GetDIE(name string) (interface{}, error) {
+	if d, ok := n.di.(diGetter); ok {
+		return d.GetE(name)
+	}
+	return n.di.Get(name), nil
+}
+
+// diProvider is implemented by DIer which supports typed injection, like *DI
+type diProvider interface {
+	Provide(constructor interface{}, lifetime Lifetime, as ...interface{}) error
+	ProvideName(name string, constructor interface{}, lifetime Lifetime) error
+	Resolve(ptr interface{}) error
+	Invoke(f interface{}) error
+	NewScope() *Scope
+}
+
+// Provide registers constructor into DI, see DI.Provide
+func (n *Nice) Provide(constructor interface{}, lifetime Lifetime, as ...interface{}) error {
+	d, ok := n.di.(diProvider)
+	if !ok {
+		return ErrDIUnsupported
+	}
+	return d.Provide(constructor, lifetime, as...)
+}
+
+// ProvideName registers constructor of the named service into DI, it is
+// created when GetDI(name) is called first time, see DI.ProvideName
+//
+// Example:
+//	app.ProvideName("db", func() (*nice.Mysql, error) {
+//		return nice.OpenMysql(app.Conf["mysql"])
+//	}, nice.Singleton)
+func (n *Nice) ProvideName(name string, constructor interface{}, lifetime Lifetime) error {
+	d, ok := n.di.(diProvider)
+	if !ok {
+		return ErrDIUnsupported
+	}
+	return d.ProvideName(name, constructor, lifetime)
+}
+
+// Resolve sets the value ptr points to with the instance of its type in DI
+func (n *Nice) Resolve(ptr interface{}) error {
+	d, ok := n.di.(diProvider)
+	if !ok {
+		return ErrDIUnsupported
+	}
+	return d.Resolve(ptr)
+}
+
+// Invoke calls f with params resolved from DI by type
+func (n *Nice) Invoke(f interface{}) error {
+	d, ok := n.di.(diProvider)
+	if !ok {
+		return ErrDIUnsupported
+	}
+	return d.Invoke(f)
+}
+
 // Static set static file route
 // h used for set Expries ...
 func (n *Nice) Static(prefix string, dir string, index bool, h HandlerFunc) {
@@ -262,7 +657,7 @@ func (n *Nice) SetAutoTrailingSlash(v bool) {
 // Example:
 // 		nice.Route("/", "GET,POST", h)
 func (n *Nice) Route(pattern, methods string, h ...HandlerFunc) RouteNode {
-	var ru RouteNode
+	var ru routeNodes
 	var ms []string
 	if methods == "*" {
 		for m := range RouterMethods {
@@ -272,11 +667,20 @@ func (n *Nice) Route(pattern, methods string, h ...HandlerFunc) RouteNode {
 		ms = strings.Split(methods, ",")
 	}
 	for _, m := range ms {
-		ru = n.Router().Add(strings.TrimSpace(m), pattern, h)
+		ru = append(ru, n.Router().Add(strings.TrimSpace(m), pattern, h))
 	}
 	return ru
 }
 
+// AddMethod registers a custom HTTP method, then it can be used in n.Route
+//
+// Example:
+//	nice.AddMethod("PROPFIND")
+//	nice.Route("/dav/*", "PROPFIND", h)
+func (n *Nice) AddMethod(method string) {
+	n.Router().AddMethod(method)
+}
+
 // Group registers a list of same prefix route
 func (n *Nice) Group(pattern string, f func(), h ...HandlerFunc) {
 	n.Router().GroupAdd(pattern, f, h)
@@ -284,9 +688,9 @@ func (n *Nice) Group(pattern string, f func(), h ...HandlerFunc) {
 
 // Any is a shortcut for n.Router().handle("*", pattern, handlers)
 func (n *Nice) Any(pattern string, h ...HandlerFunc) RouteNode {
-	var ru RouteNode
+	var ru routeNodes
 	for m := range RouterMethods {
-		ru = n.Router().Add(m, pattern, h)
+		ru = append(ru, n.Router().Add(m, pattern, h))
 	}
 	return ru
 }
@@ -326,8 +730,13 @@ func (n *Nice) Put(pattern string, h ...HandlerFunc) RouteNode {
 	return n.Router().Add("PUT", pattern, h)
 }
 
-// SetNotFound set not found route handler
+// SetNotFound set not found route handler,
+// it is only for the host when called in Host.
 func (n *Nice) SetNotFound(h HandlerFunc) {
+	if n.host != nil {
+		n.host.notFound = h
+		return
+	}
 	n.notFoundHandler = h
 }
 
@@ -340,6 +749,19 @@ func (n *Nice) NotFound(c *Context) {
 	http.NotFound(c.Resp, c.Req)
 }
 
+// SetMethodNotAllowed set method not allowed handler, it is executed when
+// the uri has route only under other methods, the Allow header has been set.
+// Set nil to disable it, then not found handler is executed.
+func (n *Nice) SetMethodNotAllowed(h HandlerFunc) {
+	n.notAllowHandler = h
+}
+
+// SetAutoOptions sets whether answer OPTIONS request automatically with
+// the Allow header when the uri has route but no OPTIONS route.
+func (n *Nice) SetAutoOptions(v bool) {
+	n.autoOptions = v
+}
+
 // SetError set error handler
 func (n *Nice) SetError(h ErrorHandleFunc) {
 	n.errorHandler = h
@@ -350,17 +772,12 @@ func (n *Nice) Error(err error, c *Context) {
 	if err == nil {
 		err = errors.New("Internal Server Error")
 	}
+	c.err = err
 	if n.errorHandler != nil {
 		n.errorHandler(err, c)
 		return
 	}
-	code := http.StatusInternalServerError
-	msg := http.StatusText(code)
-	if n.debug {
-		msg = err.Error()
-	}
-	n.Logger().Println(err)
-	http.Error(c.Resp, msg, code)
+	n.DefaultErrorHandler(err, c)
 }
 
 // DefaultNotFoundHandler invokes the default HTTP error handler.
@@ -370,12 +787,63 @@ func (n *Nice) DefaultNotFoundHandler(c *Context) {
 	http.Error(c.Resp, msg, code)
 }
 
-// URLFor use named route return format url
+// DefaultMethodNotAllowedHandler responds 405 Method Not Allowed.
+func (n *Nice) DefaultMethodNotAllowedHandler(c *Context) {
+	code := http.StatusMethodNotAllowed
+	msg := http.StatusText(code)
+	http.Error(c.Resp, msg, code)
+}
+
+// URLFor use named route return url, returns empty if error, see URLForE
 func (n *Nice) URLFor(name string, args ...interface{}) string {
-	return n.Router().URLFor(name, args...)
+	url, _ := n.URLForE(name, args...)
+	return url
+}
+
+// URLForE use named route return url, args could be P for named params,
+// Q or url.Values for query, others are positional params in order.
+// routes of hosts and mounted applications are searched when the name is
+// not found in the default route table, then the application mounted to.
+func (n *Nice) URLForE(name string, args ...interface{}) (string, error) {
+	url, err := n.urlFor(name, args...)
+	if err == nil {
+		return n.mountPrefix() + url, nil
+	}
+	if err == ErrRouteNotFound && n.parent != nil {
+		return n.parent.nice.URLForE(name, args...)
+	}
+	return "", err
+}
+
+// urlFor searches named route in the application and applications mounted
+func (n *Nice) urlFor(name string, args ...interface{}) (string, error) {
+	if url, err := n.liveRouter().URLForE(name, args...); err != ErrRouteNotFound {
+		return url, err
+	}
+	for _, h := range n.hosts {
+		if url, err := h.router.URLForE(name, args...); err != ErrRouteNotFound {
+			return url, err
+		}
+	}
+	for _, m := range n.mounts {
+		url, err := m.sub.urlFor(name, args...)
+		if err == ErrRouteNotFound {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if url == "/" || strings.HasPrefix(url, "/?") {
+			return m.prefix + url[1:], nil
+		}
+		return m.prefix + url, nil
+	}
+	return "", ErrRouteNotFound
 }
 
 //加载配置 绝对路径
+//
+// Deprecated: use Nice.LoadConfig, which returns error and supports more sources.
 func LoadConfig(yamlfile string) map[string]interface{} {
 	conf := make(map[string]interface{})
 
@@ -392,6 +860,68 @@ func LoadConfig(yamlfile string) map[string]interface{} {
 	return conf
 }
 
+// LoadConfig loads config from sources in order, the later overrides the former,
+// values are set into n.Conf. validators and change handlers are kept when called again.
+// n.Conf is not changed by WatchConfig, use n.Config() to read reloaded values.
+//
+// Example:
+//	err := app.LoadConfig(
+//		nice.ConfigFile("conf/app.yaml"),
+//		nice.ConfigEnv("APP"),
+//		nice.ConfigFlags(nil),
+//	)
+func (n *Nice) LoadConfig(sources ...ConfigSource) error {
+	c := n.Config()
+	c.mutex.Lock()
+	c.sources = sources
+	c.mutex.Unlock()
+	if err := c.Load(); err != nil {
+		return err
+	}
+	n.Conf = c.Map()
+	return nil
+}
+
+// Config returns config of the application, empty if not loaded
+func (n *Nice) Config() *Config {
+	n.configOnce.Do(func() {
+		n.config = NewConfig(ConfigMap(n.Conf))
+		n.config.Load()
+	})
+	return n.config
+}
+
+// OnConfigChange registers handler called when value of the key is changed
+// by reloading config, the key is empty for any change.
+//
+// Example:
+//	app.OnConfigChange("log.level", func(old, new interface{}) {
+//		app.Logger().Printf("log level changed: %v -> %v", old, new)
+//	})
+func (n *Nice) OnConfigChange(key string, f func(old, new interface{})) {
+	n.Config().OnChange(key, f)
+}
+
+// ValidateConfig registers validators of config, config is not loaded or
+// reloaded if any of them returns error
+func (n *Nice) ValidateConfig(f ...func(*Config) error) {
+	n.Config().Validate(f...)
+}
+
+// WatchConfig reloads config when the config files are modified, checks every
+// interval, errors are logged. watching is stopped when the application shutdown.
+func (n *Nice) WatchConfig(interval time.Duration) {
+	c := n.Config()
+	c.OnError(func(err error) {
+		n.Logger().Printf("Reload config failed: %v", err)
+	})
+	c.Watch(interval)
+	n.OnStop(func() error {
+		c.StopWatch()
+		return nil
+	})
+}
+
 // wrapMiddleware wraps middleware.
 func wrapMiddleware(m Middleware) HandlerFunc {
 	switch m := m.(type) {
@@ -399,10 +929,12 @@ func wrapMiddleware(m Middleware) HandlerFunc {
 		return m
 	case func(*Context):
 		return m
-	case http.Handler, http.HandlerFunc:
-		return WrapHandlerFunc(func(c *Context) {
-			m.(http.Handler).ServeHTTP(c.Resp, c.Req)
-		})
+	case HandlerFuncE:
+		return HandlerE(m)
+	case func(*Context) error:
+		return HandlerE(m)
+	case http.Handler:
+		return WrapHTTP(m)
 	case func(http.ResponseWriter, *http.Request):
 		return WrapHandlerFunc(func(c *Context) {
 			m(c.Resp, c.Req)
@@ -412,6 +944,37 @@ func wrapMiddleware(m Middleware) HandlerFunc {
 	}
 }
 
+// HandlerE returns a HandlerFunc of h, the returned error is handled
+// by the error handler and breaks the handler chain.
+//
+// Example:
+//	app.Get("/user/:id", nice.HandlerE(func(c *nice.Context) error {
+//		id, err := c.ParamIntE("id")
+//		if err != nil {
+//			return err
+//		}
+//		c.JSON(200, id)
+//		return nil
+//	}))
+func HandlerE(h HandlerFuncE) HandlerFunc {
+	return func(c *Context) {
+		if err := h(c); err != nil {
+			c.Error(err)
+			c.Break()
+		}
+	}
+}
+
+// WrapHTTP returns a HandlerFunc serves the request by h, then calls the next handler
+//
+// Example:
+//	app.Get("/debug/vars", nice.WrapHTTP(expvar.Handler()))
+func WrapHTTP(h http.Handler) HandlerFunc {
+	return WrapHandlerFunc(func(c *Context) {
+		h.ServeHTTP(c.Resp, c.Req)
+	})
+}
+
 // WrapHandlerFunc wrap for context handler chain
 func WrapHandlerFunc(h HandlerFunc) HandlerFunc {
 	return func(c *Context) {
@@ -1,11 +1,13 @@
 package nice
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -56,6 +58,121 @@ func TestRun1(t *testing.T) {
 	})
 }
 
+func TestShutdown1(t *testing.T) {
+	Convey("shutdown nice app", t, func() {
+		b3 := New()
+		var seq []string
+		b3.OnStart(func() error {
+			seq = append(seq, "start1")
+			return nil
+		}, func() error {
+			seq = append(seq, "start2")
+			return nil
+		})
+		b3.OnStop(func() error {
+			seq = append(seq, "stop1")
+			return nil
+		}, func() error {
+			seq = append(seq, "stop2")
+			return nil
+		})
+		b3.Get("/slow", func(c *Context) {
+			time.Sleep(100 * time.Millisecond)
+			c.String(200, "done")
+		})
+		done := make(chan struct{})
+		go func() {
+			b3.Run(":8016")
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		resp := make(chan int, 1)
+		go func() {
+			res, err := http.Get("http://127.0.0.1:8016/slow")
+			if err != nil {
+				resp <- 0
+				return
+			}
+			res.Body.Close()
+			resp <- res.StatusCode
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		So(b3.Shutdown(ctx), ShouldBeNil)
+		So(<-resp, ShouldEqual, http.StatusOK)
+		<-done
+		So(seq, ShouldResemble, []string{"start1", "start2", "stop2", "stop1"})
+		So(b3.Shutdown(ctx), ShouldBeNil)
+
+		// not listened after shutdown
+		b3.Run(":8016")
+		_, err := http.Get("http://127.0.0.1:8016/slow")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestShutdown2(t *testing.T) {
+	Convey("run waits shutdown called by others", t, func() {
+		b3 := New()
+		stopped := make(chan bool, 1)
+		b3.OnStop(func() error {
+			time.Sleep(50 * time.Millisecond)
+			stopped <- true
+			return nil
+		})
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			b3.Shutdown(context.Background())
+		}()
+		b3.Run(":8017")
+		So(len(stopped), ShouldEqual, 1)
+	})
+}
+
+func TestShutdown3(t *testing.T) {
+	Convey("shutdown timeout does not close di", t, func() {
+		b3 := New()
+		var seq []string
+		b3.SetDI("db", &closeService{name: "db", seq: &seq})
+		b3.OnStop(func() error {
+			seq = append(seq, "stop")
+			return nil
+		})
+		release := make(chan struct{})
+		b3.Get("/slow", func(c *Context) {
+			<-release
+			c.String(200, "done")
+		})
+		done := make(chan struct{})
+		go func() {
+			b3.Run(":8018")
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		resp := make(chan error, 1)
+		go func() {
+			res, err := http.Get("http://127.0.0.1:8018/slow")
+			if err == nil {
+				res.Body.Close()
+			}
+			resp <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		So(b3.Shutdown(ctx), ShouldEqual, context.DeadlineExceeded)
+		So(<-resp, ShouldNotBeNil)
+		<-done
+		So(seq, ShouldResemble, []string{"stop"})
+		close(release)
+	})
+}
+
 func TestServeHTTP1(t *testing.T) {
 	Convey("ServeHTTP", t, func() {
 		Convey("normal serve", func() {
@@ -158,6 +275,65 @@ func TestServeHTTP1(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(string(body), ShouldEqual, "ok1")
 		})
+		Convey("handler returns error", func() {
+			b2 := New()
+			var err error
+			b2.Use(func(c *Context) error {
+				c.Next()
+				err = c.Err()
+				return nil
+			})
+			b2.Get("/ok", HandlerE(func(c *Context) error {
+				c.String(200, "ok")
+				return nil
+			}))
+			b2.Get("/error", HandlerE(func(c *Context) error {
+				return NewHTTPError(http.StatusConflict)
+			}))
+			b2.Group("/g", func() {
+				b2.Post("/error", func(c *Context) {
+					c.String(200, "unreachable")
+				})
+			}, HandlerE(func(c *Context) error {
+				return fmt.Errorf("BOMB")
+			}))
+
+			req, _ := http.NewRequest("GET", "/ok", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(err, ShouldBeNil)
+
+			req, _ = http.NewRequest("GET", "/error", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusConflict)
+			So(err, ShouldNotBeNil)
+			So(err.(*HTTPError).Code, ShouldEqual, http.StatusConflict)
+
+			req, _ = http.NewRequest("POST", "/g/error", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(err.Error(), ShouldEqual, "BOMB")
+		})
+		Convey("typed handler adapters", func() {
+			b2 := New()
+			hs := []HandlerFunc{func(c *Context) {
+				c.Resp.Header().Set("X-Chain", "1")
+				c.Next()
+			}, WrapHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("http"))
+			}))}
+			b2.Get("/http", hs...)
+
+			req, _ := http.NewRequest("GET", "/http", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("X-Chain"), ShouldEqual, "1")
+			So(w.Body.String(), ShouldEqual, "http")
+		})
 		Convey("Unknow Middleware", func() {
 			b2 := New()
 			defer func() {
@@ -0,0 +1,473 @@
+package nice
+
+import (
+	"html/template"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// route meta keys for OpenAPI
+const (
+	// MetaRequest is the request type of route, it is bound by Context.Bind
+	MetaRequest = "openapi.request"
+	// MetaResponse is the response type of route, it is responded as JSON
+	MetaResponse = "openapi.response"
+	// MetaHidden hides the route from OpenAPI document
+	MetaHidden = "openapi.hidden"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// OpenAPIInfo is the info object of OpenAPI document
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	// SwaggerUI is the base url of swagger-ui-dist files of the docs page,
+	// default is DefaultSwaggerUI, set it to a self hosted copy for offline.
+	SwaggerUI string `json:"-"`
+}
+
+// DefaultSwaggerUI is the default base url of swagger-ui-dist files
+const DefaultSwaggerUI = "https://unpkg.com/swagger-ui-dist@5"
+
+// openAPIBuilder generates OpenAPI document from routes
+type openAPIBuilder struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string // component names of types
+}
+
+// OpenAPI generates OpenAPI 3 document from registered routes,
+// route name is the operationId, description is the summary, tags are the tags,
+// request and response types are set by route meta MetaRequest and MetaResponse.
+//
+// Example:
+//	nice.Post("/users", h).Name("createUser").Tag("user").
+//		Meta(nice.MetaRequest, CreateUser{}).Meta(nice.MetaResponse, User{})
+func (n *Nice) OpenAPI(info OpenAPIInfo) map[string]interface{} {
+	b := &openAPIBuilder{schemas: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+	paths := make(map[string]interface{})
+	opIDs := make(map[string]int)
+	for _, ri := range n.Routes() {
+		if hidden, _ := ri.Meta[MetaHidden].(bool); hidden {
+			continue
+		}
+		if ri.Name != "" {
+			opIDs[ri.Name]++
+		}
+	}
+	for _, ri := range n.Routes() {
+		if hidden, _ := ri.Meta[MetaHidden].(bool); hidden {
+			continue
+		}
+		for _, pattern := range optionalPatterns(ri.Pattern) {
+			path, op := b.operation(ri, pattern)
+			if ri.Name != "" && opIDs[ri.Name] > 1 {
+				op["operationId"] = ri.Name + "_" + strings.ToLower(ri.Method)
+			}
+			item, _ := paths[path].(map[string]interface{})
+			if item == nil {
+				item = make(map[string]interface{})
+				paths[path] = item
+			}
+			item[strings.ToLower(ri.Method)] = op
+		}
+	}
+
+	if info.Title == "" {
+		info.Title = n.name
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    info,
+		"paths":   paths,
+	}
+	if len(b.schemas) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": b.schemas}
+	}
+	return doc
+}
+
+// ServeOpenAPI serves OpenAPI document at path/openapi.json and a docs page at path
+//
+// Example:
+//	nice.ServeOpenAPI("/docs", nice.OpenAPIInfo{Title: "API", Version: "1.0"})
+func (n *Nice) ServeOpenAPI(path string, info OpenAPIInfo) {
+	path = strings.TrimRight(path, "/")
+	specURL := path + "/openapi.json"
+	n.Get(specURL, func(c *Context) {
+		c.JSON(200, n.OpenAPI(info))
+	}).Meta(MetaHidden, true)
+	ui := strings.TrimRight(info.SwaggerUI, "/")
+	if ui == "" {
+		ui = DefaultSwaggerUI
+	}
+	page := strings.Replace(openAPIPage, "{{title}}", template.HTMLEscapeString(info.Title), 1)
+	page = strings.Replace(page, "{{url}}", specURL, 1)
+	page = strings.Replace(page, "{{ui}}", template.HTMLEscapeString(ui), -1)
+	docs := func(c *Context) {
+		c.Text(200, []byte(page))
+	}
+	if path == "" {
+		n.Get("/", docs).Meta(MetaHidden, true)
+	} else {
+		n.Get(path, docs).Meta(MetaHidden, true)
+	}
+}
+
+// operation returns OpenAPI path and operation object of the route
+func (b *openAPIBuilder) operation(ri RouteInfo, pattern string) (string, map[string]interface{}) {
+	op := map[string]interface{}{}
+	if ri.Name != "" {
+		op["operationId"] = ri.Name
+	}
+	if ri.Description != "" {
+		op["summary"] = ri.Description
+	}
+	if len(ri.Tags) > 0 {
+		op["tags"] = ri.Tags
+	}
+
+	// path params
+	var path strings.Builder
+	var params []interface{}
+	pathParams := make(map[string]bool)
+	for _, seg := range parseURLSegments(pattern) {
+		if seg.param == "" {
+			path.WriteString(seg.static)
+			continue
+		}
+		name := seg.param
+		if seg.wide {
+			name = "path"
+		}
+		path.WriteString("{" + name + "}")
+		schema := map[string]interface{}{"type": "string"}
+		if seg.constraint != nil {
+			switch seg.constraint.expr {
+			case "int":
+				schema = map[string]interface{}{"type": "integer", "format": "int64"}
+			case "uint":
+				schema = map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+			case "float":
+				schema = map[string]interface{}{"type": "number", "format": "double"}
+			case "uuid":
+				schema["format"] = "uuid"
+			case "alpha":
+				schema["pattern"] = "^[a-zA-Z]+$"
+			case "alnum":
+				schema["pattern"] = "^[a-zA-Z0-9]+$"
+			default:
+				schema["pattern"] = "^(?:" + seg.constraint.expr + ")$"
+			}
+		}
+		pathParams[seg.param] = true
+		params = append(params, map[string]interface{}{"name": name, "in": "path", "required": true, "schema": schema})
+	}
+
+	// request type
+	if t := metaType(ri.Meta[MetaRequest]); t != nil && t.Kind() == reflect.Struct {
+		for _, p := range b.parameters(t) {
+			if p["in"] == "path" && pathParams[p["name"].(string)] {
+				continue
+			}
+			params = append(params, p)
+		}
+		if ri.Method != "GET" && ri.Method != "HEAD" && ri.Method != "DELETE" {
+			if body := b.requestBody(t); body != nil {
+				op["requestBody"] = body
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	// response type
+	resp := map[string]interface{}{"description": "OK"}
+	if t := metaType(ri.Meta[MetaResponse]); t != nil {
+		resp["content"] = map[string]interface{}{
+			ApplicationJSON: map[string]interface{}{"schema": b.schema(t)},
+		}
+	}
+	op["responses"] = map[string]interface{}{"200": resp}
+
+	p := path.String()
+	if p == "" {
+		p = "/"
+	}
+	return p, op
+}
+
+// metaType returns type of the meta value, value could be a reflect.Type
+func metaType(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// parameters returns params of request type from param, query and cookie tags
+func (b *openAPIBuilder) parameters(t reflect.Type) []map[string]interface{} {
+	var params []map[string]interface{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			params = append(params, b.parameters(sf.Type)...)
+			continue
+		}
+		for _, src := range [][2]string{{tagParam, "path"}, {tagQuery, "query"}, {tagCookie, "cookie"}} {
+			in := src[1]
+			name := sf.Tag.Get(src[0])
+			if name == "" || name == "-" {
+				continue
+			}
+			schema, required := b.fieldSchema(sf)
+			params = append(params, map[string]interface{}{
+				"name":     name,
+				"in":       in,
+				"required": required || in == "path",
+				"schema":   schema,
+			})
+		}
+	}
+	return params
+}
+
+// requestBody returns request body of the fields which are not params
+func (b *openAPIBuilder) requestBody(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	var form, file bool
+	b.bodyFields(t, props, &required, &form, &file)
+	if len(props) == 0 {
+		return nil
+	}
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	content := make(map[string]interface{})
+	switch {
+	case file:
+		content[MultipartForm] = map[string]interface{}{"schema": schema}
+	case form:
+		content[ApplicationForm] = map[string]interface{}{"schema": schema}
+		content[ApplicationJSON] = map[string]interface{}{"schema": schema}
+	default:
+		content[ApplicationJSON] = map[string]interface{}{"schema": schema}
+	}
+	return map[string]interface{}{"content": content}
+}
+
+// bodyFields collects fields of request body
+func (b *openAPIBuilder) bodyFields(t reflect.Type, props map[string]interface{}, required *[]string, form, file *bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			b.bodyFields(sf.Type, props, required, form, file)
+			continue
+		}
+		if sf.Tag.Get(tagParam) != "" || sf.Tag.Get(tagQuery) != "" || sf.Tag.Get(tagCookie) != "" || sf.Tag.Get("json") == "-" {
+			continue
+		}
+		if sf.Tag.Get(tagForm) != "" {
+			*form = true
+			if sf.Type == fileHeaderType || sf.Type == fileHeaderSliceType {
+				*file = true
+			}
+		}
+		name := fieldName(sf)
+		schema, req := b.fieldSchema(sf)
+		props[name] = schema
+		if req {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// fieldSchema returns schema of struct field with validate rules, and if it is required
+func (b *openAPIBuilder) fieldSchema(sf reflect.StructField) (map[string]interface{}, bool) {
+	schema := b.schema(sf.Type)
+	required := false
+	rules := sf.Tag.Get(tagValidate)
+	if rules == "" || rules == "-" || schema["$ref"] != nil {
+		return schema, strings.Contains(rules, "required")
+	}
+	t := sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, pair := range parseRules(rules) {
+		rule, param := pair[0], pair[1]
+		switch rule {
+		case "required":
+			required = true
+		case "min", "max":
+			v, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			key := rule + "imum"
+			switch t.Kind() {
+			case reflect.String:
+				key = rule + "Length"
+			case reflect.Slice, reflect.Array:
+				key = rule + "Items"
+			case reflect.Map:
+				key = rule + "Properties"
+			}
+			schema[key] = v
+		case "enum":
+			var enum []interface{}
+			for _, e := range strings.Split(param, "|") {
+				enum = append(enum, e)
+			}
+			schema["enum"] = enum
+		case "regex":
+			schema["pattern"] = param
+		}
+	}
+	return schema, required
+}
+
+// schema returns JSON schema of type, named struct is added to components
+func (b *openAPIBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		if t == fileHeaderType {
+			return map[string]interface{}{"type": "string", "format": "binary"}
+		}
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = b.componentName(t)
+			b.names[t] = name
+			// placeholder for recursive types
+			b.schemas[name] = nil
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// componentName returns the schema name of type, it is the type name,
+// or qualified by package path if the name is used by a type of other package.
+func (b *openAPIBuilder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, ok := b.schemas[name]; !ok {
+		return name
+	}
+	qualified := strings.Replace(t.PkgPath(), "/", ".", -1) + "." + name
+	name = qualified
+	// local types of functions have same package path
+	for i := 2; ; i++ {
+		if _, ok := b.schemas[name]; !ok {
+			return name
+		}
+		name = qualified + strconv.Itoa(i)
+	}
+}
+
+// structSchema returns object schema of struct
+func (b *openAPIBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	b.structFields(t, props, &required)
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// structFields collects properties of struct, embedded struct is flattened
+func (b *openAPIBuilder) structFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.Tag.Get("json") == "" {
+			b.structFields(sf.Type, props, required)
+			continue
+		}
+		if sf.Tag.Get("json") == "-" {
+			continue
+		}
+		name := fieldName(sf)
+		schema, req := b.fieldSchema(sf)
+		props[name] = schema
+		if req {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// openAPIPage is the docs page renders OpenAPI document by Swagger UI
+const openAPIPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<link rel="stylesheet" href="{{ui}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{ui}}/swagger-ui-bundle.js"></script>
+<script>
+window.onload = function() {
+	SwaggerUIBundle({url: "{{url}}", dom_id: "#swagger-ui"});
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,103 @@
+package nice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type openAPIUser struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Friends []*openAPIUser `json:"friends,omitempty"`
+}
+
+type openAPIUpdateUser struct {
+	ID     int64  `param:"id"`
+	Notify bool   `query:"notify"`
+	Name   string `json:"name" validate:"required,max=8"`
+	Role   string `json:"role" validate:"enum=admin|user"`
+}
+
+func TestOpenAPI1(t *testing.T) {
+	Convey("openapi document", t, func() {
+		b2 := New()
+		b2.Put("/users/:id<int>", f).Name("updateUser").Tag("user").Describe("update user").
+			Meta(MetaRequest, openAPIUpdateUser{}).Meta(MetaResponse, &openAPIUser{})
+		b2.Get("/users/:id<int>", f).Name("user").Meta(MetaResponse, openAPIUser{})
+		b2.Get("/files/*", f)
+		b2.Get("/hidden", f).Meta(MetaHidden, true)
+		b2.ServeOpenAPI("/docs", OpenAPIInfo{Title: "Nice API", Version: "1.0"})
+
+		req, _ := http.NewRequest("GET", "/docs/openapi.json", nil)
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		var doc map[string]interface{}
+		So(json.Unmarshal(w.Body.Bytes(), &doc), ShouldBeNil)
+		So(doc["openapi"], ShouldEqual, "3.0.3")
+		So(doc["info"].(map[string]interface{})["title"], ShouldEqual, "Nice API")
+
+		paths := doc["paths"].(map[string]interface{})
+		So(paths, ShouldContainKey, "/users/{id}")
+		So(paths, ShouldContainKey, "/files/{path}")
+		So(paths, ShouldNotContainKey, "/hidden")
+		So(paths, ShouldNotContainKey, "/docs")
+
+		put := paths["/users/{id}"].(map[string]interface{})["put"].(map[string]interface{})
+		So(put["operationId"], ShouldEqual, "updateUser")
+		So(put["summary"], ShouldEqual, "update user")
+		params := put["parameters"].([]interface{})
+		So(params, ShouldHaveLength, 2)
+		So(params[0].(map[string]interface{})["schema"].(map[string]interface{})["type"], ShouldEqual, "integer")
+		So(params[1].(map[string]interface{})["name"], ShouldEqual, "notify")
+
+		body := put["requestBody"].(map[string]interface{})["content"].(map[string]interface{})[ApplicationJSON].(map[string]interface{})["schema"].(map[string]interface{})
+		So(body["required"], ShouldResemble, []interface{}{"name"})
+		props := body["properties"].(map[string]interface{})
+		So(props["name"].(map[string]interface{})["maxLength"], ShouldEqual, 8)
+		So(props["role"].(map[string]interface{})["enum"], ShouldResemble, []interface{}{"admin", "user"})
+
+		schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		So(schemas, ShouldContainKey, "openAPIUser")
+		friends := schemas["openAPIUser"].(map[string]interface{})["properties"].(map[string]interface{})["friends"].(map[string]interface{})
+		So(friends["items"].(map[string]interface{})["$ref"], ShouldEqual, "#/components/schemas/openAPIUser")
+
+		req, _ = http.NewRequest("GET", "/docs", nil)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Body.String(), ShouldContainSubstring, "/docs/openapi.json")
+		So(w.Body.String(), ShouldContainSubstring, DefaultSwaggerUI+"/swagger-ui-bundle.js")
+	})
+}
+
+func TestOpenAPI2(t *testing.T) {
+	Convey("same named types and swagger ui url", t, func() {
+		user := reflect.TypeOf(openAPIUser{})
+		type openAPIUser struct {
+			Nick string `json:"nick"`
+		}
+		b2 := New()
+		b2.Get("/users/:id", f).Meta(MetaResponse, user)
+		b2.Get("/members/:id", f).Meta(MetaResponse, openAPIUser{})
+		b2.ServeOpenAPI("/docs", OpenAPIInfo{Title: "Nice API", SwaggerUI: "/static/swagger/"})
+
+		doc := b2.OpenAPI(OpenAPIInfo{})
+		schemas := doc["components"].(map[string]interface{})["schemas"]
+		So(schemas, ShouldHaveLength, 2)
+		So(schemas, ShouldContainKey, "openAPIUser")
+		So(schemas, ShouldContainKey, strings.Replace(user.PkgPath(), "/", ".", -1)+".openAPIUser")
+
+		req, _ := http.NewRequest("GET", "/docs", nil)
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Body.String(), ShouldContainSubstring, `src="/static/swagger/swagger-ui-bundle.js"`)
+		So(w.Body.String(), ShouldNotContainSubstring, "unpkg.com")
+	})
+}
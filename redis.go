@@ -1,9 +1,12 @@
 package nice
 
 import (
+	"context"
 	redislib "github.com/gomodule/redigo/redis"
-	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/otel/attribute"
 	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,6 +18,7 @@ type Redis struct {
 	idle     int
 	active   int
 	pool     *redislib.Pool
+	mutex    sync.RWMutex
 }
 
 type RedisCnf struct {
@@ -24,36 +28,110 @@ type RedisCnf struct {
 }
 
 type RedisNode struct {
-	Host     string `yaml: "host"`
-	Password string `yaml: "password"`
-	Index    int    `yaml: "index"`
-	MaxOpen  int    `yaml: "maxopen"` //maxOpenConn
-	MaxIdle  int    `yaml: "maxidle"` //maxIdleConn
+	Host     string `yaml:"host" default:"127.0.0.1:6379"`
+	Password string `yaml:"password"`
+	Index    int    `yaml:"index"`
+	MaxOpen  int    `yaml:"maxopen"` //maxOpenConn
+	MaxIdle  int    `yaml:"maxidle"` //maxIdleConn
 }
 
+// NewRedis create redis pool, config could be RedisNode, *RedisNode or map, panics if failed
 func NewRedis(config interface{}) *Redis {
+	r, err := OpenRedis(config)
+	if err != nil {
+		log.Panicln("Init redis pool failed.", err.Error())
+	}
+	return r
+}
 
+// OpenRedis create redis pool, config could be RedisNode, *RedisNode or map
+func OpenRedis(config interface{}) (*Redis, error) {
 	conf := RedisNode{}
-	err := mapstructure.Decode(config.(map[interface{}]interface{}), &conf)
+	if err := decodeConf(config, &conf); err != nil {
+		return nil, err
+	}
 
 	r := &Redis{
 		host:     conf.Host,
 		password: conf.Password,
 		database: conf.Index,
-		idle:     conf.MaxOpen,
-		active:   conf.MaxIdle,
+		idle:     conf.MaxIdle,
+		active:   conf.MaxOpen,
 	}
 	r.Open()
-	if _, err = r.Do("PING"); err != nil {
-		log.Panicln("Init redis pool failed.", err.Error())
+	if _, err := r.Do("PING"); err != nil {
+		r.Close()
+		return nil, err
 	}
-	return r
+	return r, nil
 }
 
 func (r *Redis) Open() {
-	r.pool = &redislib.Pool{
-		MaxActive:   r.active, // max number of connections
-		MaxIdle:     r.idle,
+	r.mutex.Lock()
+	r.pool = r.newPool(r.active, r.idle)
+	r.mutex.Unlock()
+}
+
+// SetPoolSize replaces the pool with a new one of the size, it could be called when config changed.
+// It waits for the pending gets of the old pool before closing it, and
+// connections in use of the old pool are closed after being returned.
+func (r *Redis) SetPoolSize(maxOpen, maxIdle int) {
+	r.mutex.Lock()
+	old := r.pool
+	r.active, r.idle = maxOpen, maxIdle
+	r.pool = r.newPool(maxOpen, maxIdle)
+	r.mutex.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// ResizeOnChange resizes the pool by maxopen and maxidle under key of c
+// when they are changed by reloading c, like the config watched by WatchConfig.
+//
+// Example:
+//	app.Cache().(*nice.Redis).ResizeOnChange(app.Config(), "redis")
+func (r *Redis) ResizeOnChange(c *Config, key string) {
+	c.OnChange(key, func(old, new interface{}) {
+		var conf RedisNode
+		if err := c.Decode(key, &conf); err != nil {
+			c.reportError(err)
+			return
+		}
+		r.mutex.RLock()
+		same := conf.MaxOpen == r.active && conf.MaxIdle == r.idle
+		r.mutex.RUnlock()
+		if !same {
+			r.SetPoolSize(conf.MaxOpen, conf.MaxIdle)
+		}
+	})
+}
+
+// Stats returns stats of the current pool
+func (r *Redis) Stats() PoolStats {
+	s := r.getPool().Stats()
+	return PoolStats{ActiveCount: s.ActiveCount, IdleCount: s.IdleCount, WaitCount: s.WaitCount, WaitDuration: s.WaitDuration}
+}
+
+// getPool returns the current pool
+func (r *Redis) getPool() *redislib.Pool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.pool
+}
+
+// getConn gets a connection from the current pool, the pool is not replaced before it returns
+func (r *Redis) getConn(ctx context.Context) (redislib.Conn, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.pool.GetContext(ctx)
+}
+
+// newPool create redis pool
+func (r *Redis) newPool(active, idle int) *redislib.Pool {
+	return &redislib.Pool{
+		MaxActive:   active, // max number of connections
+		MaxIdle:     idle,
 		IdleTimeout: 120 * time.Second,
 		Dial: func() (redislib.Conn, error) {
 			c, err := redislib.Dial("tcp", r.host)
@@ -81,13 +159,33 @@ func (r *Redis) Open() {
 
 // Close pool
 func (r *Redis) Close() error {
-	err := r.pool.Close()
+	err := r.getPool().Close()
 	return err
 }
 
-// Do commands
+// Do commands, it is traced as a root span
 func (r *Redis) Do(command string, args ...interface{}) (interface{}, error) {
-	conn := r.pool.Get()
+	return r.DoContext(context.Background(), command, args...)
+}
+
+// DoContext commands with context, the deadline of ctx is used as command timeout
+func (r *Redis) DoContext(ctx context.Context, command string, args ...interface{}) (_ interface{}, err error) {
+	ctx, span := startSpan(ctx, "redis."+strings.ToLower(command),
+		attribute.String("db.system.name", "redis"),
+		attribute.String("db.operation.name", strings.ToUpper(command)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	conn, err := r.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return redislib.DoWithTimeout(conn, time.Until(deadline), command, args...)
+	}
 	return conn.Do(command, args...)
 }
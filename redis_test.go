@@ -0,0 +1,63 @@
+package nice
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRedisStats1(t *testing.T) {
+	Convey("stats of cache pool", t, func() {
+		b2 := New()
+		r := &Redis{active: 3, idle: 1}
+		r.Open()
+		b2.SetDI("cache", r)
+
+		So(b2.Cache().(CacheStats).Stats(), ShouldResemble, PoolStats{})
+		So(b2.di.(*DI).Close(), ShouldBeNil)
+	})
+}
+
+func TestRedisPool1(t *testing.T) {
+	Convey("resize pool when getting connections", t, func() {
+		r := &Redis{host: "127.0.0.1:1", active: 2, idle: 1}
+		r.Open()
+
+		stop := make(chan struct{})
+		resized := make(chan struct{})
+		go func() {
+			defer close(resized)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					r.SetPoolSize(3+i%2, 1)
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		var closed int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					if _, err := r.Do("GET", "k"); err != nil && strings.Contains(err.Error(), "closed pool") {
+						atomic.AddInt32(&closed, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(stop)
+		<-resized
+
+		So(closed, ShouldEqual, 0)
+		So(r.Close(), ShouldBeNil)
+	})
+}
@@ -15,6 +15,8 @@ type Response struct {
 	resp        http.ResponseWriter
 	writer      io.Writer
 	nice        *Nice
+	before      []func() // called before writing header
+	discard     bool     // response is written by a before func, later writes are dropped
 }
 
 // NewResponse ...
@@ -45,6 +47,9 @@ func (r *Response) Write(n []byte) (int, error) {
 	if !r.wroteHeader {
 		r.WriteHeader(http.StatusOK)
 	}
+	if r.discard {
+		return len(n), nil
+	}
 	c, err := r.writer.Write(n)
 	r.written += int64(c)
 	return c, err
@@ -57,14 +62,34 @@ func (r *Response) Write(n []byte) (int, error) {
 // send error codes.
 func (r *Response) WriteHeader(code int) {
 	if r.wroteHeader {
-		r.nice.Logger().Println("http: multiple response.WriteHeader calls")
+		if !r.discard {
+			r.nice.Logger().Println("http: multiple response.WriteHeader calls")
+		}
 		return
 	}
+	if len(r.before) > 0 {
+		before := r.before
+		r.before = nil
+		for _, f := range before {
+			f()
+		}
+		if r.wroteHeader {
+			// replaced by the response written in before func, like an error
+			r.discard = true
+			return
+		}
+	}
 	r.wroteHeader = true
 	r.status = code
 	r.resp.WriteHeader(code)
 }
 
+// Before registers f which is called once before writing header, f could
+// write another response, like an error, then the response being written is dropped.
+func (r *Response) Before(f func()) {
+	r.before = append(r.before, f)
+}
+
 // Flush implements the http.Flusher interface to allow an HTTP handler to flush
 // buffered data to the client.
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
@@ -93,6 +118,8 @@ func (r *Response) reset(w http.ResponseWriter) {
 	r.resp = w
 	r.writer = w
 	r.wroteHeader = false
+	r.before = nil
+	r.discard = false
 	r.written = 0
 	r.status = http.StatusOK
 }
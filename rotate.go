@@ -0,0 +1,214 @@
+package nice
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat is the time format in name of rotated files
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateFile is an io.Writer writes to file, the file is rotated by size or time,
+// rotated files are named with rotation time, like access-2006-01-02T15-04-05.000.log,
+// or with beginning of the interval when Interval is set, and removed by MaxAge and MaxBackups. it is safe for concurrent use.
+//
+// Example:
+//	w := &nice.RotateFile{Filename: "logs/access.log", MaxSize: 100 << 20, Interval: 24 * time.Hour, MaxAge: 7 * 24 * time.Hour}
+//	app.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{Format: middleware.LogFormatJSON, Output: w}))
+//	app.OnStop(func() error { return w.Close() })
+type RotateFile struct {
+	// Filename is the file to write, directory is created if not exists
+	Filename string
+	// MaxSize rotates the file before it exceeds MaxSize bytes, 0 is unlimited
+	MaxSize int64
+	// Interval rotates the file at the beginning of each interval in local time,
+	// like time.Hour or 24 * time.Hour, 0 disables time based rotation
+	Interval time.Duration
+	// MaxAge removes rotated files older than MaxAge, 0 keeps all
+	MaxAge time.Duration
+	// MaxBackups keeps at most MaxBackups rotated files, 0 keeps all
+	MaxBackups int
+
+	mutex  sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time        // beginning of interval which the file belongs to
+	now    func() time.Time // for test
+}
+
+// Write implements io.Writer, it rotates the file when needed
+func (r *RotateFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := r.timeNow()
+	if (r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize) ||
+		(r.Interval > 0 && !r.periodOf(now).Equal(r.period)) {
+		if err := r.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the file and renames it, a new file is created for next writes,
+// it could be called on signal like SIGHUP.
+func (r *RotateFile) Rotate() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	return r.rotate(r.timeNow())
+}
+
+// Close closes the file
+func (r *RotateFile) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// timeNow returns current time
+func (r *RotateFile) timeNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// periodOf returns beginning of the interval of t in local time
+func (r *RotateFile) periodOf(t time.Time) time.Time {
+	if r.Interval <= 0 {
+		return time.Time{}
+	}
+	_, offset := t.Zone()
+	d := time.Duration(offset) * time.Second
+	return t.Add(d).Truncate(r.Interval).Add(-d)
+}
+
+// open opens the file for appending, it belongs to the interval of last modified
+func (r *RotateFile) open() error {
+	if r.Filename == "" {
+		return fmt.Errorf("nice: filename of RotateFile is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	r.period = r.periodOf(r.timeNow())
+	if r.size > 0 {
+		r.period = r.periodOf(info.ModTime())
+	}
+	return nil
+}
+
+// rotate renames current file with time, or with the interval it belongs to, then opens a new file and removes old files
+func (r *RotateFile) rotate(now time.Time) error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	if r.size > 0 {
+		ext := filepath.Ext(r.Filename)
+		prefix := strings.TrimSuffix(r.Filename, ext) + "-"
+		stamp := now.Local().Format(rotateTimeFormat)
+		if r.Interval > 0 {
+			stamp = r.period.Local().Format(rotateTimeFormat)
+		}
+		name := prefix + stamp + ext
+		for i := 1; fileExists(name); i++ {
+			name = prefix + stamp + fmt.Sprintf(".%d", i) + ext
+		}
+		if err := os.Rename(r.Filename, name); err != nil {
+			return err
+		}
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.period = r.periodOf(now)
+	return r.removeOld(now)
+}
+
+// removeOld removes rotated files by MaxAge and MaxBackups
+func (r *RotateFile) removeOld(now time.Time) error {
+	if r.MaxAge <= 0 && r.MaxBackups <= 0 {
+		return nil
+	}
+	dir := filepath.Dir(r.Filename)
+	ext := filepath.Ext(r.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(r.Filename), ext) + "-"
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type backup struct {
+		name string
+		time time.Time
+	}
+	var backups []backup
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(name[len(prefix):], ext)
+		if len(stamp) < len(rotateTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(rotateTimeFormat, stamp[:len(rotateTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name, t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	for i, b := range backups {
+		if (r.MaxBackups > 0 && i >= r.MaxBackups) || (r.MaxAge > 0 && now.Sub(b.time) > r.MaxAge) {
+			if err := os.Remove(filepath.Join(dir, b.name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fileExists returns whether the file exists
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,81 @@
+package nice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func rotatedFiles(dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, "access-*.log"))
+	return files
+}
+
+func TestRotateFile1(t *testing.T) {
+	Convey("rotate file", t, func() {
+		dir, _ := ioutil.TempDir("", "nice")
+		defer os.RemoveAll(dir)
+		now := time.Date(2024, 1, 2, 23, 59, 0, 0, time.Local)
+		w := &RotateFile{Filename: filepath.Join(dir, "logs", "access.log"), now: func() time.Time { return now }}
+		defer w.Close()
+
+		Convey("by size", func() {
+			w.MaxSize = 11
+			w.Write([]byte("12345\n"))
+			w.Write([]byte("1234\n"))
+			So(rotatedFiles(dir), ShouldBeEmpty)
+			now = now.Add(time.Millisecond)
+			w.Write([]byte("abc\n"))
+			files := rotatedFiles(filepath.Join(dir, "logs"))
+			So(files, ShouldHaveLength, 1)
+			So(filepath.Base(files[0]), ShouldEqual, "access-2024-01-02T23-59-00.001.log")
+			b, _ := ioutil.ReadFile(files[0])
+			So(string(b), ShouldEqual, "12345\n1234\n")
+			b, _ = ioutil.ReadFile(w.Filename)
+			So(string(b), ShouldEqual, "abc\n")
+		})
+		Convey("by time", func() {
+			w.Interval = 24 * time.Hour
+			w.Write([]byte("day1\n"))
+			now = now.Add(30 * time.Second)
+			w.Write([]byte("day1\n"))
+			So(rotatedFiles(filepath.Join(dir, "logs")), ShouldBeEmpty)
+			now = now.Add(time.Minute)
+			w.Write([]byte("day2\n"))
+			files := rotatedFiles(filepath.Join(dir, "logs"))
+			So(files, ShouldHaveLength, 1)
+			So(filepath.Base(files[0]), ShouldEqual, "access-2024-01-02T00-00-00.000.log")
+			b, _ := ioutil.ReadFile(files[0])
+			So(string(b), ShouldEqual, "day1\nday1\n")
+			b, _ = ioutil.ReadFile(w.Filename)
+			So(string(b), ShouldEqual, "day2\n")
+		})
+		Convey("retention", func() {
+			w.MaxBackups = 2
+			w.MaxAge = time.Hour
+			for i := 0; i < 4; i++ {
+				w.Write([]byte("line\n"))
+				now = now.Add(time.Second)
+				So(w.Rotate(), ShouldBeNil)
+			}
+			files := rotatedFiles(filepath.Join(dir, "logs"))
+			So(files, ShouldHaveLength, 2)
+			So(strings.HasSuffix(files[0], "23-59-03.000.log"), ShouldBeTrue)
+
+			now = now.Add(2 * time.Hour)
+			w.Write([]byte("line\n"))
+			So(w.Rotate(), ShouldBeNil)
+			So(rotatedFiles(filepath.Join(dir, "logs")), ShouldHaveLength, 1)
+		})
+		Convey("errors", func() {
+			_, err := (&RotateFile{}).Write([]byte("x"))
+			So(err, ShouldNotBeNil)
+			So((&RotateFile{}).Close(), ShouldBeNil)
+		})
+	})
+}
@@ -1,5 +1,9 @@
 package nice
 
+import (
+	"time"
+)
+
 const (
 	GET int = iota
 	POST
@@ -43,21 +47,95 @@ type Router interface {
 	SetAutoTrailingSlash(v bool)
 	// Match find matched route then returns handlers and name
 	Match(method, uri string, c *Context) ([]HandlerFunc, string)
-	// URLFor use named route return format url
+	// AllowedMethods returns the methods which has route matched the uri
+	AllowedMethods(uri string) []string
+	// AddMethod registers a custom HTTP method, like PROPFIND, MKCOL
+	AddMethod(method string)
+	// URLFor use named route return url, returns empty if error
 	URLFor(name string, args ...interface{}) string
+	// URLForE use named route return url, returns error if route not found or params invalid
+	URLForE(name string, args ...interface{}) (string, error)
 	// Add registers a new handle with the given method, pattern and handlers.
 	Add(method, pattern string, handlers []HandlerFunc) RouteNode
+	// Remove removes the route registered with method and pattern
+	Remove(method, pattern string) bool
 	// GroupAdd registers a list of same prefix route
 	GroupAdd(pattern string, f func(), handlers []HandlerFunc)
 	// Routes returns registered route uri in a string slice
 	Routes() map[string][]string
 	// NamedRoutes returns named route uri in a string slice
 	NamedRoutes() map[string]string
+	// RouteInfos returns registered routes in order
+	RouteInfos() []RouteInfo
+	// Conflicts returns warnings of ambiguous routes
+	Conflicts() []string
 }
 
 // RouteNode is an router node
 type RouteNode interface {
-	Name(name string)
+	// Name set name of route
+	Name(name string) RouteNode
+	// Timeout set the max duration of the route
+	Timeout(d time.Duration) RouteNode
+	// Use registers middleware only for the route
+	Use(m ...Middleware) RouteNode
+	// Meta set a metadata of the route
+	Meta(key string, v interface{}) RouteNode
+	// Tag adds tags to the route
+	Tag(tags ...string) RouteNode
+	// Describe set description of the route
+	Describe(desc string) RouteNode
+}
+
+// routeNodes is a group of route nodes registered by one call with multiple methods
+type routeNodes []RouteNode
+
+// Name set name of routes, URLFor uses the last one
+func (r routeNodes) Name(name string) RouteNode {
+	for i := range r {
+		r[i].Name(name)
+	}
+	return r
+}
+
+// Timeout set the max duration of routes
+func (r routeNodes) Timeout(d time.Duration) RouteNode {
+	for i := range r {
+		r[i].Timeout(d)
+	}
+	return r
+}
+
+// Use registers middleware for routes
+func (r routeNodes) Use(m ...Middleware) RouteNode {
+	for i := range r {
+		r[i].Use(m...)
+	}
+	return r
+}
+
+// Meta set a metadata of routes
+func (r routeNodes) Meta(key string, v interface{}) RouteNode {
+	for i := range r {
+		r[i].Meta(key, v)
+	}
+	return r
+}
+
+// Tag adds tags to routes
+func (r routeNodes) Tag(tags ...string) RouteNode {
+	for i := range r {
+		r[i].Tag(tags...)
+	}
+	return r
+}
+
+// Describe set description of routes
+func (r routeNodes) Describe(desc string) RouteNode {
+	for i := range r {
+		r[i].Describe(desc)
+	}
+	return r
 }
 
 // IsParamChar check the char can used for route params
@@ -0,0 +1,101 @@
+package nice
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RouteInfo is structured info of a registered route
+type RouteInfo struct {
+	Method      string                 `json:"method"`
+	Host        string                 `json:"host,omitempty"`
+	Pattern     string                 `json:"pattern"`
+	Name        string                 `json:"name,omitempty"`
+	Middleware  []string               `json:"middleware,omitempty"` // group handlers and route middleware
+	Handlers    []string               `json:"handlers"`
+	Meta        map[string]interface{} `json:"meta,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Timeout     time.Duration          `json:"timeout,omitempty"`
+}
+
+// handlerName returns function name of the handler
+func handlerName(h interface{}) string {
+	v := reflect.ValueOf(h)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Sprintf("%T", h)
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// Routes returns registered routes in order, include routes of hosts
+// and applications mounted with full pattern.
+func (n *Nice) Routes() []RouteInfo {
+	infos := n.liveRouter().RouteInfos()
+	for _, h := range n.hosts {
+		for _, info := range h.router.RouteInfos() {
+			info.Host = h.pattern
+			infos = append(infos, info)
+		}
+	}
+	for _, m := range n.mounts {
+		for _, info := range m.sub.Routes() {
+			info.Pattern = strings.TrimRight(m.prefix+info.Pattern, "/")
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// CheckRoutes returns warnings of ambiguous routes, include routes of
+// hosts and applications mounted. It is called when the application runs.
+func (n *Nice) CheckRoutes() []string {
+	warnings := n.liveRouter().Conflicts()
+	for _, h := range n.hosts {
+		for _, w := range h.router.Conflicts() {
+			warnings = append(warnings, h.pattern+" "+w)
+		}
+	}
+	for _, m := range n.mounts {
+		for _, w := range m.sub.CheckRoutes() {
+			warnings = append(warnings, m.prefix+" "+w)
+		}
+	}
+	return warnings
+}
+
+// PrintRoutes writes the route table to w
+func (n *Nice) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tHOST\tPATTERN\tNAME\tHANDLERS")
+	for _, info := range n.Routes() {
+		handlers := append(append([]string{}, info.Middleware...), info.Handlers...)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", info.Method, info.Host, info.Pattern, info.Name, strings.Join(handlers, " -> "))
+	}
+	tw.Flush()
+}
+
+// RoutesHandler returns a handler responds the route table, JSON by default,
+// plain text when query format=text. It is for debug, don't expose it in public.
+//
+// Example:
+//	nice.Get("/debug/routes", nice.RoutesHandler())
+func (n *Nice) RoutesHandler() HandlerFunc {
+	return func(c *Context) {
+		if c.Query("format") == "text" {
+			c.Resp.Header().Set("Content-Type", TextPlainCharsetUTF8)
+			c.Resp.WriteHeader(200)
+			n.PrintRoutes(c.Resp)
+			return
+		}
+		c.JSON(200, n.Routes())
+	}
+}
@@ -0,0 +1,99 @@
+package nice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func routesAuth(c *Context) {
+	c.Next()
+}
+
+func routesAuthE(c *Context) error {
+	c.Next()
+	return nil
+}
+
+func routesUser(c *Context) {
+	c.String(200, "user")
+}
+
+func TestRoutes1(t *testing.T) {
+	Convey("route table introspection", t, func() {
+		b2 := New()
+		b2.Group("/api", func() {
+			b2.Get("/users/:id", routesUser).Use(routesAuth).Name("user").Meta("scope", "admin").Tag("user")
+			b2.Get("/users", routesUser).Use(routesAuthE)
+		}, routesAuth)
+		b2.Host("admin.example.com", func() {
+			b2.Post("/login", routesUser)
+		})
+		sub := New()
+		sub.Get("/", routesUser)
+		b2.Mount("/sub", sub)
+
+		infos := b2.Routes()
+		So(infos[0].Method, ShouldEqual, "GET")
+		So(infos[0].Pattern, ShouldEqual, "/api/users/:id")
+		So(infos[0].Name, ShouldEqual, "user")
+		So(handlerName(routesAuth), ShouldEndWith, "nice.routesAuth")
+		So(infos[0].Middleware, ShouldResemble, []string{handlerName(routesAuth), handlerName(routesAuth)})
+		So(infos[0].Handlers, ShouldResemble, []string{handlerName(routesUser)})
+		So(infos[0].Meta["scope"], ShouldEqual, "admin")
+		So(infos[1].Middleware, ShouldResemble, []string{handlerName(routesAuth), handlerName(routesAuthE)})
+
+		var host, mounted bool
+		for _, info := range infos {
+			if info.Host == "admin.example.com" && info.Pattern == "/login" {
+				host = true
+			}
+			if info.Pattern == "/sub" && info.Handlers[0] == handlerName(routesUser) {
+				mounted = true
+			}
+		}
+		So(host, ShouldBeTrue)
+		So(mounted, ShouldBeTrue)
+
+		buf := new(bytes.Buffer)
+		b2.PrintRoutes(buf)
+		So(buf.String(), ShouldContainSubstring, "/api/users/:id")
+
+		b2.Get("/debug/routes", b2.RoutesHandler())
+		req, _ := http.NewRequest("GET", "/debug/routes", nil)
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		var v []RouteInfo
+		So(json.Unmarshal(w.Body.Bytes(), &v), ShouldBeNil)
+		So(v[0].Pattern, ShouldEqual, "/api/users/:id")
+	})
+}
+
+func TestRoutes2(t *testing.T) {
+	Convey("route conflict detection", t, func() {
+		b2 := New()
+		b2.Get("/a/:x", f).Name("a")
+		So(func() { b2.Get("/a/:y/b", f) }, ShouldPanic)
+		So(func() { b2.Get("/b", f).Name("a") }, ShouldPanic)
+		So(func() { b2.Post("/a/:x", f).Name("a") }, ShouldNotPanic)
+
+		So(b2.CheckRoutes(), ShouldBeEmpty)
+		b2.Get("/n/:id<int>", f)
+		b2.Get("/n/:id<uint>", f)
+		warnings := b2.CheckRoutes()
+		So(warnings, ShouldHaveLength, 1)
+		So(strings.HasPrefix(warnings[0], "GET /n/"), ShouldBeTrue)
+
+		// constrained param before unconstrained one
+		b2.Get("/m/:id<int>", f)
+		b2.Get("/m/:name", f)
+		warnings = b2.CheckRoutes()
+		So(warnings, ShouldHaveLength, 2)
+		So(warnings, ShouldContain, "GET /m/: ambiguous params [:id<int>, :name], matched in this order")
+	})
+}
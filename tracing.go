@@ -0,0 +1,46 @@
+package nice
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of spans created by nice
+const tracerName = "github.com/nic-chen/nice"
+
+// Tracer returns the tracer of nice from the tracer provider of the span in ctx,
+// so spans follow the provider of the request, like TracingConfig.TracerProvider.
+// the global tracer provider is used if ctx has no recording span.
+func Tracer(ctx context.Context) trace.Tracer {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		return span.TracerProvider().Tracer(tracerName)
+	}
+	return otel.Tracer(tracerName)
+}
+
+// TracePropagator returns the W3C trace context and baggage propagator,
+// it is used to extract traceparent of requests and inject it into calls.
+func TracePropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// startSpan starts a client span of db or cache call, it is a child of the span
+// in ctx, or a root span if ctx carries no span, like calls of Query and Do.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer(ctx).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error then ends the span, sql.ErrNoRows is not an error
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,37 @@
+// Package tracing provider the OpenTelemetry sdk setup for nice.
+package tracing
+
+import (
+	"io"
+
+	nice "../"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Init sets a global tracer provider exports spans of the service by exporter
+// in batch, and the W3C propagator. shutdown the provider to flush spans on exit.
+//
+// Example:
+//	exporter, _ := tracing.NewStdoutExporter(os.Stdout)
+//	tp := tracing.Init("user", exporter)
+//	app.OnStop(func() error { return tp.Shutdown(context.Background()) })
+//	app.Use(middleware.Tracing())
+func Init(serviceName string, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(nice.TracePropagator())
+	return tp
+}
+
+// NewStdoutExporter returns an exporter writes spans to w as json, for development
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+}
+
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInit1(t *testing.T) {
+	Convey("init global tracer provider", t, func() {
+		global, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+		defer func() {
+			otel.SetTracerProvider(global)
+			otel.SetTextMapPropagator(propagator)
+		}()
+
+		exporter := tracetest.NewInMemoryExporter()
+		tp := Init("nice-test", exporter)
+		So(otel.GetTracerProvider(), ShouldEqual, tp)
+		So(otel.GetTextMapPropagator().Fields(), ShouldContain, "traceparent")
+
+		_, span := otel.Tracer("test").Start(context.Background(), "span")
+		span.End()
+		So(tp.ForceFlush(context.Background()), ShouldBeNil)
+		spans := exporter.GetSpans()
+		So(spans, ShouldHaveLength, 1)
+		v, _ := spans[0].Resource.Set().Value("service.name")
+		So(v.AsString(), ShouldEqual, "nice-test")
+		So(tp.Shutdown(context.Background()), ShouldBeNil)
+	})
+}
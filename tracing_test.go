@@ -0,0 +1,101 @@
+package nice
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing1(t *testing.T) {
+	Convey("child spans of db and cache", t, func() {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		global := otel.GetTracerProvider()
+		otel.SetTracerProvider(tp)
+		defer otel.SetTracerProvider(global)
+
+		db, _ := sql.Open("mysql", "root:@tcp(127.0.0.1:1)/nice")
+		p := &Mysql{Master: db, Slave: db, Loger: New().Logger()}
+		r := &Redis{host: "127.0.0.1:1"}
+		r.Open()
+
+		Convey("root span without parent", func() {
+			p.Query("SELECT 1")
+			p.QueryRow("SELECT 1")
+			p.Exec("UPDATE user SET name = ?", "nice")
+			r.Do("GET", "k")
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 4)
+			So(spans[0].Name, ShouldEqual, "mysql.query")
+			So(spans[1].Name, ShouldEqual, "mysql.query")
+			So(spans[2].Name, ShouldEqual, "mysql.exec")
+			So(spans[3].Name, ShouldEqual, "redis.get")
+			for _, s := range spans {
+				So(s.Parent.IsValid(), ShouldBeFalse)
+			}
+		})
+		Convey("spans with parent", func() {
+			ctx := TracePropagator().Extract(context.Background(), propagation.MapCarrier{
+				"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			})
+			p.QueryContext(ctx, "SELECT 1")
+			p.ExecContext(ctx, "UPDATE user SET name = ?", "nice")
+			r.DoContext(ctx, "GET", "k")
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 3)
+			So(spans[0].Name, ShouldEqual, "mysql.query")
+			So(spans[1].Name, ShouldEqual, "mysql.exec")
+			So(spans[2].Name, ShouldEqual, "redis.get")
+			for _, s := range spans {
+				So(s.SpanKind, ShouldEqual, trace.SpanKindClient)
+				So(s.Parent.SpanID().String(), ShouldEqual, "00f067aa0ba902b7")
+				So(s.SpanContext.TraceID().String(), ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+				So(s.Status.Code, ShouldEqual, codes.Error)
+			}
+			So(spans[1].Attributes, ShouldContain, mysqlAttrs("exec", "UPDATE user SET name = ?")[2])
+		})
+		Convey("spans with provider of parent", func() {
+			exporter2 := tracetest.NewInMemoryExporter()
+			tp2 := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter2))
+			ctx, span := tp2.Tracer("test").Start(context.Background(), "parent")
+			r.DoContext(ctx, "GET", "k")
+			span.End()
+
+			So(exporter.GetSpans(), ShouldBeEmpty)
+			spans := exporter2.GetSpans()
+			So(spans, ShouldHaveLength, 2)
+			So(spans[0].Name, ShouldEqual, "redis.get")
+			So(spans[0].Parent.SpanID(), ShouldEqual, span.SpanContext().SpanID())
+		})
+		Convey("trace id in request log", func() {
+			var buf bytes.Buffer
+			b2 := New()
+			b2.SetDI("logger", NewLog(&buf, InfoLevel, JSONEncoder{}))
+			b2.Get("/trace", func(c *Context) {
+				ctx, span := Tracer(c.Ctx()).Start(c.Ctx(), "handler")
+				defer span.End()
+				c.WithContext(ctx)
+				c.Log().Info("traced")
+			})
+			req, _ := http.NewRequest("GET", "/trace", nil)
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			var m map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+			So(m["trace_id"], ShouldHaveLength, 32)
+		})
+	})
+}
@@ -2,7 +2,12 @@ package nice
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -11,38 +16,137 @@ const (
 	leafKindWide
 )
 
-// Tree provlider router for nice with radix tree
+// Tree provlider router for nice with radix tree.
+// Match reads the route table without lock, after the first Match the
+// table is copy on write, changes are made on a copy then swapped atomically.
 type Tree struct {
 	autoHead          bool
 	autoTrailingSlash bool
-	mu                sync.RWMutex
+	mu                sync.Mutex // serializes writers
+	live              int32      // set by first Match
+	table             atomic.Value
 	groups            []*group
-	nodes             [RouteLength]*leaf
 	nice              *Nice
-	nameNodes         map[string]*Node
 }
 
-// Node is struct for named route
+// routeTable is the route table of Tree
+type routeTable struct {
+	nodes       [RouteLength]*leaf
+	methods     map[string]*leaf // custom methods route table
+	methodNames []string         // custom methods in registered order
+	nameNodes   map[string]*Node
+	infos       []routeEntry // registered routes in order
+}
+
+// routeEntry is a registered route
+type routeEntry struct {
+	method string
+	node   *Node
+	adds   [][2]string // method and pattern added to tree, like auto HEAD and trailing slash
+}
+
+// Node is struct for route, it holds handlers, name and metadata of the route.
+// attributes are read by Match without lock, they are copied on write.
 type Node struct {
-	paramNum int
-	pattern  string
-	format   string
-	name     string
-	root     *Tree
+	pattern string
+	root    *Tree
+	attrs   atomic.Value // *nodeAttrs
+}
+
+// nodeAttrs is the attributes of route, it is not changed once stored
+type nodeAttrs struct {
+	segments    []urlSegment // parsed pattern for URLFor
+	name        string
+	timeout     time.Duration
+	handlers    []HandlerFunc // group handlers, route middleware then route handlers
+	names       []string      // function names of handlers
+	groupNum    int           // number of group handlers
+	useNum      int           // number of route middleware
+	tags        []string
+	description string
+	meta        map[string]interface{}
 }
 
 // Leaf is a tree node
 type leaf struct {
-	kind        uint
-	pattern     string
-	param       string
-	handlers    []HandlerFunc
-	children    []*leaf
-	childrenNum uint
-	paramChild  *leaf
-	wideChild   *leaf
-	root        *Tree
-	nameNode    *Node
+	kind               uint
+	pattern            string
+	param              string
+	constraint         *constraint
+	children           []*leaf
+	childrenNum        uint
+	constraintChildren []*leaf
+	paramChild         *leaf
+	wideChild          *leaf
+	root               *Tree
+	nameNode           *Node
+}
+
+// constraint validates param value of route, like :id<int>, :name<[a-z]+>
+type constraint struct {
+	expr  string
+	match func(string) (interface{}, bool)
+}
+
+// builtin param constraints, int and uint stored as int64 and uint64, float as float64
+var constraints = map[string]func(string) (interface{}, bool){
+	"int": func(s string) (interface{}, bool) {
+		v, err := strconv.ParseInt(s, 10, 64)
+		return v, err == nil
+	},
+	"uint": func(s string) (interface{}, bool) {
+		v, err := strconv.ParseUint(s, 10, 64)
+		return v, err == nil
+	},
+	"float": func(s string) (interface{}, bool) {
+		v, err := strconv.ParseFloat(s, 64)
+		return v, err == nil
+	},
+	"alpha": regexpConstraint("[a-zA-Z]+"),
+	"alnum": regexpConstraint("[a-zA-Z0-9]+"),
+	"uuid":  regexpConstraint("[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"),
+}
+
+// regexpConstraint returns constraint matches whole value by regexp
+func regexpConstraint(expr string) func(string) (interface{}, bool) {
+	re := regexp.MustCompile("^(?:" + expr + ")$")
+	return func(s string) (interface{}, bool) {
+		return nil, re.MatchString(s)
+	}
+}
+
+// newConstraint create a builtin or regexp constraint
+func newConstraint(expr string) *constraint {
+	if expr == "" {
+		panic("route pattern param constraint is empty")
+	}
+	m, ok := constraints[expr]
+	if !ok {
+		if _, err := regexp.Compile(expr); err != nil {
+			panic("route pattern param constraint <" + expr + "> is invalid: " + err.Error())
+		}
+		m = regexpConstraint(expr)
+	}
+	return &constraint{expr: expr, match: m}
+}
+
+// paramEnd returns the end position of param begins at i in pattern,
+// the constraint in <> could contain slash.
+func paramEnd(pattern string, i int) int {
+	depth := 0
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case '/':
+			if depth <= 0 {
+				return i
+			}
+		}
+	}
+	return i
 }
 
 // group route
@@ -54,15 +158,93 @@ type group struct {
 // NewTree create a router instance
 func NewTree(n *Nice) Router {
 	t := new(Tree)
-	for i := 0; i < len(t.nodes); i++ {
-		t.nodes[i] = newLeaf("/", nil, t)
-	}
-	t.nameNodes = make(map[string]*Node)
+	t.table.Store(t.newTable(nil))
 	t.groups = make([]*group, 0)
 	t.nice = n
 	return t
 }
 
+// newTable create an empty route table with custom methods
+func (t *Tree) newTable(methods []string) *routeTable {
+	tb := new(routeTable)
+	for i := 0; i < len(tb.nodes); i++ {
+		tb.nodes[i] = newLeaf("/", nil, t)
+	}
+	tb.nameNodes = make(map[string]*Node)
+	tb.methods = make(map[string]*leaf)
+	for _, m := range methods {
+		tb.methods[m] = newLeaf("/", nil, t)
+		tb.methodNames = append(tb.methodNames, m)
+	}
+	return tb
+}
+
+// load returns current route table
+func (t *Tree) load() *routeTable {
+	return t.table.Load().(*routeTable)
+}
+
+// write changes the route table, the table is copied when the router is live,
+// before that the table is changed in place.
+func (t *Tree) write(f func(tb *routeTable)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tb := t.load()
+	if atomic.LoadInt32(&t.live) == 1 {
+		tb = tb.clone()
+	}
+	f(tb)
+	t.table.Store(tb)
+}
+
+// clone returns a deep copy of the route table, route nodes are shared
+func (tb *routeTable) clone() *routeTable {
+	c := new(routeTable)
+	for i := range tb.nodes {
+		c.nodes[i] = tb.nodes[i].clone()
+	}
+	c.methods = make(map[string]*leaf, len(tb.methods))
+	for m, l := range tb.methods {
+		c.methods[m] = l.clone()
+	}
+	c.methodNames = append([]string{}, tb.methodNames...)
+	c.nameNodes = make(map[string]*Node, len(tb.nameNodes))
+	for k, v := range tb.nameNodes {
+		c.nameNodes[k] = v
+	}
+	c.infos = append([]routeEntry{}, tb.infos...)
+	return c
+}
+
+// root returns the route table of method, nil if method is not registered
+func (tb *routeTable) root(method string) *leaf {
+	if k, ok := RouterMethods[method]; ok {
+		return tb.nodes[k]
+	}
+	return tb.methods[method]
+}
+
+// clone returns a deep copy of the leaf and its children
+func (l *leaf) clone() *leaf {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	c.children = make([]*leaf, len(l.children))
+	for i := range l.children {
+		c.children[i] = l.children[i].clone()
+	}
+	if len(l.constraintChildren) > 0 {
+		c.constraintChildren = make([]*leaf, len(l.constraintChildren))
+		for i := range l.constraintChildren {
+			c.constraintChildren[i] = l.constraintChildren[i].clone()
+		}
+	}
+	c.paramChild = l.paramChild.clone()
+	c.wideChild = l.wideChild.clone()
+	return &c
+}
+
 // NewNode create a route node
 func NewNode(pattern string, root *Tree) *Node {
 	return &Node{
@@ -71,11 +253,11 @@ func NewNode(pattern string, root *Tree) *Node {
 	}
 }
 
-// newLeaf create a tree leaf
-func newLeaf(pattern string, handlers []HandlerFunc, root *Tree) *leaf {
+// newLeaf create a tree leaf, node is nil if the leaf is not a route
+func newLeaf(pattern string, node *Node, root *Tree) *leaf {
 	l := new(leaf)
 	l.pattern = pattern
-	l.handlers = handlers
+	l.nameNode = node
 	l.root = root
 	l.kind = leafKindStatic
 	l.children = make([]*leaf, 128)
@@ -100,104 +282,161 @@ func (t *Tree) SetAutoTrailingSlash(v bool) {
 	t.autoTrailingSlash = v
 }
 
+// AddMethod registers a custom HTTP method, like PROPFIND, MKCOL,
+// the common methods in RouterMethods are always registered.
+func (t *Tree) AddMethod(method string) {
+	if !validMethod(method) {
+		panic("invalid http method [" + method + "]")
+	}
+	if _, ok := RouterMethods[method]; ok {
+		return
+	}
+	t.write(func(tb *routeTable) {
+		if _, ok := tb.methods[method]; ok {
+			return
+		}
+		tb.methods[method] = newLeaf("/", nil, t)
+		tb.methodNames = append(tb.methodNames, method)
+	})
+}
+
+// validMethod returns if method is a valid http token
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		if method[i] <= ' ' || method[i] >= 0x7f || strings.IndexByte("()<>@,;:\\\"/[]?={}", method[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Match find matched route then returns handlers and name
+// c could be nil when only check the route exists.
 func (t *Tree) Match(method, pattern string, c *Context) ([]HandlerFunc, string) {
-	var i, l int
-	var root, nl *leaf
-	root = t.nodes[RouterMethods[method]]
-	current := root
-
-	for {
-		switch current.kind {
-		case leafKindStatic:
-			// static route
-			l = len(current.pattern)
-			if l > len(pattern) {
-				break
-			}
-			i := l - 1
-			for ; i >= 0; i-- {
-				if current.pattern[i] != pattern[i] {
-					break
-				}
-			}
-			if i >= 0 {
-				break
-			}
-			if len(pattern) == l || current.children[pattern[l]] != nil ||
-				current.paramChild != nil ||
-				current.wideChild != nil {
-				pattern = pattern[l:]
-				root = current
-			}
-		case leafKindParam:
-			// params route
-			l = len(pattern)
-			for i = 0; i < l && pattern[i] != '/'; i++ {
-			}
-			c.SetParam(current.param, pattern[:i])
-			pattern = pattern[i:]
-			root = current
-		case leafKindWide:
-			// wide route
-			c.SetParam(current.param, pattern)
-			pattern = pattern[:0]
-		default:
-		}
-
-		if len(pattern) == 0 {
-			if current.handlers != nil {
-				if current.nameNode != nil {
-					return current.handlers, current.nameNode.name
-				}
-				return current.handlers, ""
-			}
-			if root.paramChild == nil && root.wideChild == nil {
-				return nil, ""
-			}
-		} else {
-			// children static route
-			if current == root {
-				if nl = root.children[pattern[0]]; nl != nil {
-					current = nl
-					continue
-				}
+	if atomic.LoadInt32(&t.live) == 0 {
+		// wait writing then mark live
+		t.mu.Lock()
+		atomic.StoreInt32(&t.live, 1)
+		t.mu.Unlock()
+	}
+	root := t.load().root(method)
+	if root == nil {
+		return nil, ""
+	}
+	node := root.match(pattern, c)
+	if node == nil {
+		return nil, ""
+	}
+	if c != nil {
+		c.route = node
+	}
+	a := node.load()
+	return a.handlers, a.name
+}
+
+// match finds route node under the leaf, children are tried in order:
+// static, constrained params, param and wide, it backtracks to the next
+// sibling when a subtree does not match.
+func (l *leaf) match(pattern string, c *Context) *Node {
+	var pn int
+	if c != nil {
+		pn = len(c.pNames)
+	}
+
+	switch l.kind {
+	case leafKindStatic:
+		if len(pattern) < len(l.pattern) || pattern[:len(l.pattern)] != l.pattern {
+			return nil
+		}
+		pattern = pattern[len(l.pattern):]
+	case leafKindParam:
+		i := strings.IndexByte(pattern, '/')
+		if i < 0 {
+			i = len(pattern)
+		}
+		var typed interface{}
+		if l.constraint != nil {
+			var ok bool
+			if typed, ok = l.constraint.match(pattern[:i]); !ok {
+				return nil
 			}
 		}
-
-		// param route
-		if root.paramChild != nil {
-			current = root.paramChild
-			continue
+		if c != nil {
+			c.setParam(l.param, pattern[:i], typed)
 		}
-
-		// wide route
-		if root.wideChild != nil {
-			current = root.wideChild
-			continue
+		pattern = pattern[i:]
+	case leafKindWide:
+		if c != nil {
+			c.SetParam(l.param, pattern)
 		}
+		return l.nameNode
+	}
 
-		break
+	if len(pattern) == 0 {
+		if l.nameNode != nil {
+			return l.nameNode
+		}
+	} else if child := l.children[pattern[0]]; child != nil {
+		if node := child.match(pattern, c); node != nil {
+			return node
+		}
+	}
+	for _, child := range l.constraintChildren {
+		if node := child.match(pattern, c); node != nil {
+			return node
+		}
+	}
+	if l.paramChild != nil {
+		if node := l.paramChild.match(pattern, c); node != nil {
+			return node
+		}
+	}
+	if l.wideChild != nil {
+		if node := l.wideChild.match(pattern, c); node != nil {
+			return node
+		}
 	}
 
-	return nil, ""
+	if c != nil {
+		c.truncateParams(pn)
+	}
+	return nil
 }
 
-// URLFor use named route return format url
-func (t *Tree) URLFor(name string, args ...interface{}) string {
-	if name == "" {
-		return ""
+// AllowedMethods returns the methods which has route matched the uri
+func (t *Tree) AllowedMethods(uri string) []string {
+	var methods []string
+	for k := 0; k < RouteLength; k++ {
+		if h, _ := t.Match(RouterMethodName[k], uri, nil); h != nil {
+			methods = append(methods, RouterMethodName[k])
+		}
 	}
-	node := t.nameNodes[name]
-	if node == nil || len(node.format) == 0 {
-		return ""
+	for _, m := range t.customMethods() {
+		if h, _ := t.Match(m, uri, nil); h != nil {
+			methods = append(methods, m)
+		}
 	}
-	format := make([]byte, len(node.format))
-	copy(format, node.format)
-	for i := node.paramNum + 1; i <= len(args); i++ {
-		format = append(format, "%v"...)
+	return methods
+}
+
+// URLFor use named route return url, returns empty if error, see URLForE
+func (t *Tree) URLFor(name string, args ...interface{}) string {
+	url, _ := t.URLForE(name, args...)
+	return url
+}
+
+// URLForE use named route return url, args could be P for named params,
+// Q or url.Values for query, others are positional params in order.
+// returns error if route not found, param missing or not match the constraint.
+func (t *Tree) URLForE(name string, args ...interface{}) (string, error) {
+	node := t.load().nameNodes[name]
+	if name == "" || node == nil {
+		return "", ErrRouteNotFound
 	}
-	return fmt.Sprintf(string(format), args...)
+	return buildURL(name, node.load().segments, args)
 }
 
 // Routes returns registered route uri in a string slice
@@ -206,27 +445,36 @@ func (t *Tree) Routes() map[string][]string {
 	for _, method := range RouterMethodName {
 		routes[method] = make([]string, 0)
 	}
-	for k := range t.nodes {
-		routes[RouterMethodName[k]] = t.routes(t.nodes[k])
+	tb := t.load()
+	for k := range tb.nodes {
+		routes[RouterMethodName[k]] = t.routes(tb.nodes[k])
+	}
+	for _, m := range tb.methodNames {
+		routes[m] = t.routes(tb.methods[m])
 	}
 
 	return routes
 }
 
+// customMethods returns registered custom methods
+func (t *Tree) customMethods() []string {
+	return t.load().methodNames
+}
+
 // routes print the route table
 func (t *Tree) routes(l *leaf) []string {
 	if l == nil {
 		return nil
 	}
 	var data []string
-	if l.handlers != nil {
+	if l.nameNode != nil {
 		data = append(data, l.String())
 	}
-	l.children = append(l.children, l.paramChild)
-	l.children = append(l.children, l.wideChild)
-	for i := range l.children {
-		if l.children[i] != nil {
-			cdata := t.routes(l.children[i])
+	children := append(l.children[:len(l.children):len(l.children)], l.constraintChildren...)
+	children = append(children, l.paramChild, l.wideChild)
+	for i := range children {
+		if children[i] != nil {
+			cdata := t.routes(children[i])
 			for i := range cdata {
 				data = append(data, l.String()+cdata[i])
 			}
@@ -236,10 +484,64 @@ func (t *Tree) routes(l *leaf) []string {
 	return data
 }
 
+// RouteInfos returns registered routes in order
+func (t *Tree) RouteInfos() []RouteInfo {
+	tb := t.load()
+	infos := make([]RouteInfo, len(tb.infos))
+	for i, e := range tb.infos {
+		infos[i] = e.node.info(e.method)
+	}
+	return infos
+}
+
+// Conflicts returns warnings of ambiguous routes, the params in same position
+// could both match, the constrained ones are matched first in registered order,
+// then the unconstrained one.
+func (t *Tree) Conflicts() []string {
+	tb := t.load()
+	var warnings []string
+	for k := range tb.nodes {
+		warnings = append(warnings, t.conflicts(RouterMethodName[k], "", tb.nodes[k])...)
+	}
+	for _, m := range tb.methodNames {
+		warnings = append(warnings, t.conflicts(m, "", tb.methods[m])...)
+	}
+	return warnings
+}
+
+// conflicts checks the leaf and its children
+func (t *Tree) conflicts(method, prefix string, l *leaf) []string {
+	var warnings []string
+	prefix += l.String()
+	params := make([]string, 0, len(l.constraintChildren)+1)
+	for _, child := range l.constraintChildren {
+		params = append(params, child.String())
+	}
+	if l.paramChild != nil && len(params) > 0 {
+		params = append(params, l.paramChild.String())
+	}
+	if len(params) > 1 {
+		warnings = append(warnings, fmt.Sprintf("%s %s: ambiguous params [%s], matched in this order",
+			method, prefix, strings.Join(params, ", ")))
+	}
+	for _, child := range l.children {
+		if child != nil {
+			warnings = append(warnings, t.conflicts(method, prefix, child)...)
+		}
+	}
+	for _, child := range l.constraintChildren {
+		warnings = append(warnings, t.conflicts(method, prefix, child)...)
+	}
+	if l.paramChild != nil {
+		warnings = append(warnings, t.conflicts(method, prefix, l.paramChild)...)
+	}
+	return warnings
+}
+
 // NamedRoutes returns named route uri in a string slice
 func (t *Tree) NamedRoutes() map[string]string {
 	routes := make(map[string]string)
-	for k, v := range t.nameNodes {
+	for k, v := range t.load().nameNodes {
 		routes[k] = v.pattern
 	}
 	return routes
@@ -248,47 +550,12 @@ func (t *Tree) NamedRoutes() map[string]string {
 // Add registers a new handle with the given method, pattern and handlers.
 // add check training slash option.
 func (t *Tree) Add(method, pattern string, handlers []HandlerFunc) RouteNode {
-	if method == "GET" && t.autoHead {
-		t.add("HEAD", pattern, handlers)
+	if t.load().root(method) == nil {
+		panic("unsupport http method [" + method + "], register it by AddMethod first")
 	}
-	if t.autoTrailingSlash && (len(pattern) > 1 || len(t.groups) > 0) {
-		var index byte
-		if len(pattern) > 0 {
-			index = pattern[len(pattern)-1]
-		}
-		if index == '/' {
-			t.add(method, pattern[:len(pattern)-1], handlers)
-		} else if index == '*' {
-			// wideChild not need trail slash
-		} else {
-			t.add(method, pattern+"/", handlers)
-		}
-	}
-	return t.add(method, pattern, handlers)
-}
-
-// GroupAdd add a group route has same prefix and handle chain
-func (t *Tree) GroupAdd(pattern string, f func(), handlers []HandlerFunc) {
-	g := newGroup()
-	g.pattern = pattern
-	g.handlers = handlers
-	t.groups = append(t.groups, g)
-
-	f()
-
-	t.groups = t.groups[:len(t.groups)-1]
-}
-
-// add registers a new request handle with the given method, pattern and handlers.
-func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
-	if _, ok := RouterMethods[method]; !ok {
-		panic("unsupport http method [" + method + "]")
-	}
-
-	t.mu.Lock()
-	defer t.mu.Unlock()
 
 	// check group set
+	var groupNum int
 	if len(t.groups) > 0 {
 		var gpattern string
 		var ghandlers []HandlerFunc
@@ -299,8 +566,8 @@ func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
 			}
 		}
 		pattern = gpattern + pattern
-		ghandlers = append(ghandlers, handlers...)
-		handlers = ghandlers
+		groupNum = len(ghandlers)
+		handlers = append(ghandlers, handlers...)
 	}
 
 	// check pattern (for training slash move behind group check)
@@ -311,27 +578,122 @@ func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
 		panic("route pattern must begin /")
 	}
 
+	node := NewNode(pattern, t)
+	a := &nodeAttrs{groupNum: groupNum}
+	a.handlers = make([]HandlerFunc, len(handlers))
+	a.names = make([]string, len(handlers))
 	for i := 0; i < len(handlers); i++ {
-		handlers[i] = WrapHandlerFunc(handlers[i])
+		a.handlers[i] = WrapHandlerFunc(handlers[i])
+		a.names[i] = handlerName(handlers[i])
 	}
+	node.attrs.Store(a)
 
-	root := t.nodes[RouterMethods[method]]
-	origPattern := pattern
-	nameNode := NewNode(origPattern, t)
+	e := routeEntry{method: method, node: node}
+	for _, pattern := range optionalPatterns(pattern) {
+		if method == "GET" && t.autoHead {
+			e.adds = append(e.adds, [2]string{"HEAD", pattern})
+		}
+		if t.autoTrailingSlash && len(pattern) > 1 {
+			index := pattern[len(pattern)-1]
+			if index == '/' {
+				e.adds = append(e.adds, [2]string{method, pattern[:len(pattern)-1]})
+			} else if index == '*' {
+				// wideChild not need trail slash
+			} else {
+				e.adds = append(e.adds, [2]string{method, pattern + "/"})
+			}
+		}
+		e.adds = append(e.adds, [2]string{method, pattern})
+	}
+	t.write(func(tb *routeTable) {
+		tb.addEntry(e)
+	})
+	return node
+}
+
+// addEntry adds the route to table
+func (tb *routeTable) addEntry(e routeEntry) {
+	for _, a := range e.adds {
+		tb.add(a[0], a[1], e.node)
+	}
+	tb.infos = append(tb.infos, e)
+}
+
+// Remove removes the route registered with method and pattern, pattern
+// includes group prefix, returns false if the route is not found.
+// The route table is rebuilt without the route.
+func (t *Tree) Remove(method, pattern string) bool {
+	found := false
+	t.write(func(tb *routeTable) {
+		var infos []routeEntry
+		for _, e := range tb.infos {
+			if e.method == method && e.node.pattern == pattern {
+				found = true
+				continue
+			}
+			infos = append(infos, e)
+		}
+		if !found {
+			return
+		}
+		nb := t.newTable(tb.methodNames)
+		for _, e := range infos {
+			nb.addEntry(e)
+			if name := e.node.GetName(); name != "" {
+				nb.nameNodes[name] = e.node
+			}
+		}
+		*tb = *nb
+	})
+	return found
+}
+
+// optionalPatterns expands the trailing optional param, /user/:id? is
+// registered as /user/:id and /user
+func optionalPatterns(pattern string) []string {
+	if pattern[len(pattern)-1] != '?' {
+		return []string{pattern}
+	}
+	full := pattern[:len(pattern)-1]
+	pos := strings.LastIndexByte(full[:strings.LastIndexByte(full, ':')+1], '/')
+	if pos < 0 || full[pos+1] != ':' || paramEnd(full, pos+1) != len(full) {
+		panic("route pattern optional param must be the last segment")
+	}
+	short := full[:pos]
+	if short == "" {
+		short = "/"
+	}
+	return []string{full, short}
+}
+
+// GroupAdd add a group route has same prefix and handle chain
+func (t *Tree) GroupAdd(pattern string, f func(), handlers []HandlerFunc) {
+	g := newGroup()
+	g.pattern = pattern
+	g.handlers = handlers
+	t.groups = append(t.groups, g)
+
+	f()
+
+	t.groups = t.groups[:len(t.groups)-1]
+}
+
+// add registers the route node with the given method and pattern.
+func (tb *routeTable) add(method, pattern string, nameNode *Node) {
+	root := tb.root(method)
+	t := root.root
 
 	// specialy route = /
 	if len(pattern) == 1 {
-		root.handlers = handlers
 		root.nameNode = nameNode
-		return nameNode
+		return
 	}
 
 	// left trim slash, because root is slash /
 	pattern = pattern[1:]
 
 	var radix []byte
-	var param []byte
-	var i, k int
+	var i int
 	var tl *leaf
 	for i = 0; i < len(pattern); i++ {
 		// wide route
@@ -341,9 +703,8 @@ func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
 				root = root.insertChild(newLeaf(string(radix), nil, t))
 				radix = radix[:0]
 			}
-			tl = newLeaf("*", handlers, t)
+			tl = newLeaf("*", nameNode, t)
 			tl.kind = leafKindWide
-			tl.nameNode = nameNode
 			root.insertChild(tl)
 			break
 		}
@@ -355,30 +716,33 @@ func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
 				root = root.insertChild(newLeaf(string(radix), nil, t))
 				radix = radix[:0]
 			}
-			// set param route
-			param = param[:0]
-			k = 0
-			for i = i + 1; i < len(pattern); i++ {
-				if pattern[i] == '/' {
-					i--
-					break
+			// set param route, with optional constraint :name<expr>
+			end := paramEnd(pattern, i+1)
+			param := pattern[i+1 : end]
+			var expr *constraint
+			if k := strings.IndexByte(param, '<'); k >= 0 {
+				if param[len(param)-1] != '>' {
+					panic("route pattern param constraint must end with >")
 				}
-				param = append(param, pattern[i])
-				k++
+				param, expr = param[:k], newConstraint(param[k+1:len(param)-1])
 			}
-			if k == 0 {
+			if param == "" {
 				panic("route pattern param is empty")
 			}
+			if strings.IndexByte(param, '?') >= 0 {
+				panic("route pattern optional param must be the last segment")
+			}
 			// check last character
-			if i == len(pattern) {
-				tl = newLeaf(":", handlers, t)
-				tl.nameNode = nameNode
+			if end == len(pattern) {
+				tl = newLeaf(":", nameNode, t)
 			} else {
 				tl = newLeaf(":", nil, t)
 			}
-			tl.param = string(param[:k])
+			tl.param = param
+			tl.constraint = expr
 			tl.kind = leafKindParam
 			root = root.insertChild(tl)
+			i = end - 1
 			continue
 		}
 		radix = append(radix, pattern[i])
@@ -386,12 +750,9 @@ func (t *Tree) add(method, pattern string, handlers []HandlerFunc) RouteNode {
 
 	// static route
 	if len(radix) > 0 {
-		tl = newLeaf(string(radix), handlers, t)
-		tl.nameNode = nameNode
+		tl = newLeaf(string(radix), nameNode, t)
 		root.insertChild(tl)
 	}
-
-	return nameNode
 }
 
 // insertChild insert child into root route, and returns the child route
@@ -405,6 +766,27 @@ func (l *leaf) insertChild(node *leaf) *leaf {
 		return node
 	}
 
+	// constrained param route
+	if node.kind == leafKindParam && node.constraint != nil {
+		for _, child := range l.constraintChildren {
+			if child.constraint.expr != node.constraint.expr {
+				continue
+			}
+			if child.param != node.param {
+				panic("Router Tree.insert error cannot use two param [:" + child.param + ", :" + node.param + "] with same constraint <" + node.constraint.expr + ">!")
+			}
+			if node.nameNode != nil {
+				if child.nameNode != nil {
+					panic("Router Tree.insert error: cannot twice set handler for same route")
+				}
+				child.nameNode = node.nameNode
+			}
+			return child
+		}
+		l.constraintChildren = append(l.constraintChildren, node)
+		return node
+	}
+
 	// param route
 	if node.kind == leafKindParam {
 		if l.paramChild == nil {
@@ -414,11 +796,10 @@ func (l *leaf) insertChild(node *leaf) *leaf {
 		if l.paramChild.param != node.param {
 			panic("Router Tree.insert error cannot use two param [:" + l.paramChild.param + ", :" + node.param + "] with same prefix!")
 		}
-		if node.handlers != nil {
-			if l.paramChild.handlers != nil {
+		if node.nameNode != nil {
+			if l.paramChild.nameNode != nil {
 				panic("Router Tree.insert error: cannot twice set handler for same route")
 			}
-			l.paramChild.handlers = node.handlers
 			l.paramChild.nameNode = node.nameNode
 		}
 		return l.paramChild
@@ -438,11 +819,10 @@ func (l *leaf) insertChild(node *leaf) *leaf {
 	if pos == len(child.pattern) {
 		// same route
 		if pos == len(node.pattern) {
-			if node.handlers != nil {
-				if child.handlers != nil {
+			if node.nameNode != nil {
+				if child.nameNode != nil {
 					panic("Router Tree.insert error: cannot twice set handler for same route")
 				}
-				child.handlers = node.handlers
 				child.nameNode = node.nameNode
 			}
 			return child
@@ -453,17 +833,16 @@ func (l *leaf) insertChild(node *leaf) *leaf {
 		return child.insertChild(node)
 	}
 
-	newChild := newLeaf(child.pattern[pos:], child.handlers, child.root)
-	newChild.nameNode = child.nameNode
+	newChild := newLeaf(child.pattern[pos:], child.nameNode, child.root)
 	newChild.children = child.children
 	newChild.childrenNum = child.childrenNum
+	newChild.constraintChildren = child.constraintChildren
 	newChild.paramChild = child.paramChild
 	newChild.wideChild = child.wideChild
 
 	// node is prefix of child
 	if pos == len(node.pattern) {
-		child.reset(node.pattern, node.handlers)
-		child.nameNode = node.nameNode
+		child.reset(node.pattern, node.nameNode)
 		child.children[newChild.pattern[0]] = newChild
 		child.childrenNum++
 		return child
@@ -480,15 +859,15 @@ func (l *leaf) insertChild(node *leaf) *leaf {
 }
 
 // resetPattern reset route pattern and alpha
-func (l *leaf) reset(pattern string, handlers []HandlerFunc) {
+func (l *leaf) reset(pattern string, node *Node) {
 	l.pattern = pattern
 	l.children = make([]*leaf, 128)
 	l.childrenNum = 0
+	l.constraintChildren = nil
 	l.paramChild = nil
 	l.wideChild = nil
-	l.nameNode = nil
+	l.nameNode = node
 	l.param = ""
-	l.handlers = handlers
 }
 
 // hasPrefixString returns the same prefix position, if none return 0
@@ -508,34 +887,174 @@ func (l *leaf) String() string {
 	s := l.pattern
 	if l.kind == leafKindParam {
 		s += l.param
+		if l.constraint != nil {
+			s += "<" + l.constraint.expr + ">"
+		}
 	}
 	return s
 }
 
+// load returns attributes of the route
+func (n *Node) load() *nodeAttrs {
+	if a, ok := n.attrs.Load().(*nodeAttrs); ok {
+		return a
+	}
+	return &nodeAttrs{}
+}
+
+// copyAttrs returns a copy of attributes to change, tags and meta are copied
+func (n *Node) copyAttrs() *nodeAttrs {
+	a := *n.load()
+	a.tags = append([]string{}, a.tags...)
+	meta := make(map[string]interface{}, len(a.meta))
+	for k, v := range a.meta {
+		meta[k] = v
+	}
+	a.meta = meta
+	return &a
+}
+
+// update changes a copy of attributes by f then stores it
+func (n *Node) update(f func(a *nodeAttrs)) RouteNode {
+	n.root.mu.Lock()
+	defer n.root.mu.Unlock()
+	a := n.copyAttrs()
+	f(a)
+	n.attrs.Store(a)
+	return n
+}
+
 // Name set name of route
-func (n *Node) Name(name string) {
+func (n *Node) Name(name string) RouteNode {
 	if name == "" {
-		return
+		return n
 	}
-	p := 0
-	f := make([]byte, 0, len(n.pattern))
-	for i := 0; i < len(n.pattern); i++ {
-		if n.pattern[i] != ':' {
-			f = append(f, n.pattern[i])
-			continue
+	n.root.write(func(tb *routeTable) {
+		if old, ok := tb.nameNodes[name]; ok && old.pattern != n.pattern {
+			panic("Router Tree.Name error: route name [" + name + "] is used by " + old.pattern)
 		}
-		f = append(f, '%')
-		f = append(f, 'v')
-		p++
-		for i = i + 1; i < len(n.pattern); i++ {
-			if n.pattern[i] == '/' {
-				i--
-				break
-			}
+		a := n.copyAttrs()
+		a.segments = parseURLSegments(n.pattern)
+		a.name = name
+		n.attrs.Store(a)
+		tb.nameNodes[name] = n
+	})
+	return n
+}
+
+// Timeout set the max duration of the route, the request context
+// will be canceled when timeout, see Context.Ctx()
+func (n *Node) Timeout(d time.Duration) RouteNode {
+	return n.update(func(a *nodeAttrs) {
+		a.timeout = d
+	})
+}
+
+// Use registers middleware only for the route, they are executed after
+// global middleware and group handlers, before route handlers.
+func (n *Node) Use(m ...Middleware) RouteNode {
+	var mws []HandlerFunc
+	var names []string
+	for i := range m {
+		if m[i] != nil {
+			mws = append(mws, WrapHandlerFunc(wrapMiddleware(m[i])))
+			names = append(names, handlerName(m[i]))
+		}
+	}
+	return n.update(func(a *nodeAttrs) {
+		pos := a.groupNum + a.useNum
+		handlers := make([]HandlerFunc, 0, len(a.handlers)+len(mws))
+		handlers = append(handlers, a.handlers[:pos]...)
+		handlers = append(handlers, mws...)
+		handlers = append(handlers, a.handlers[pos:]...)
+		a.handlers = handlers
+		a.names = append(append(append([]string{}, a.names[:pos]...), names...), a.names[pos:]...)
+		a.useNum += len(mws)
+	})
+}
+
+// Meta set a metadata of the route, like auth scopes, rate limit class.
+// it can be read by Context.RouteMeta at request time
+func (n *Node) Meta(key string, v interface{}) RouteNode {
+	return n.update(func(a *nodeAttrs) {
+		a.meta[key] = v
+	})
+}
+
+// Tag adds tags to the route
+func (n *Node) Tag(tags ...string) RouteNode {
+	return n.update(func(a *nodeAttrs) {
+		a.tags = append(a.tags, tags...)
+	})
+}
+
+// Describe set description of the route
+func (n *Node) Describe(desc string) RouteNode {
+	return n.update(func(a *nodeAttrs) {
+		a.description = desc
+	})
+}
+
+// GetName returns name of the route
+func (n *Node) GetName() string {
+	return n.load().name
+}
+
+// Pattern returns pattern of the route
+func (n *Node) Pattern() string {
+	return n.pattern
+}
+
+// GetMeta returns metadata of the route, nil if not set
+func (n *Node) GetMeta(key string) interface{} {
+	return n.load().meta[key]
+}
+
+// Tags returns tags of the route
+func (n *Node) Tags() []string {
+	return n.load().tags
+}
+
+// HasTag returns if the route has the tag
+func (n *Node) HasTag(tag string) bool {
+	tags := n.load().tags
+	for i := range tags {
+		if tags[i] == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Description returns description of the route
+func (n *Node) Description() string {
+	return n.load().description
+}
+
+// getTimeout returns the max duration of the route
+func (n *Node) getTimeout() time.Duration {
+	return n.load().timeout
+}
+
+// info returns structured info of the route
+func (n *Node) info(method string) RouteInfo {
+	a := n.load()
+	pos := a.groupNum + a.useNum
+	info := RouteInfo{
+		Method:      method,
+		Pattern:     n.pattern,
+		Name:        a.name,
+		Middleware:  append([]string{}, a.names[:pos]...),
+		Handlers:    append([]string{}, a.names[pos:]...),
+		Tags:        a.tags,
+		Description: a.description,
+		Timeout:     a.timeout,
+	}
+	if len(a.meta) > 0 {
+		info.Meta = make(map[string]interface{}, len(a.meta))
+		for k, v := range a.meta {
+			info.Meta[k] = v
 		}
 	}
-	n.format = string(f)
-	n.paramNum = p
-	n.name = name
-	n.root.nameNodes[name] = n
+	return info
 }
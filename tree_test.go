@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -12,9 +13,10 @@ import (
 // print the route map
 func (t *Tree) print(prefix string, root *leaf) {
 	if root == nil {
-		for m := range t.nodes {
+		tb := t.load()
+		for m := range tb.nodes {
 			fmt.Println(m)
-			t.print("", t.nodes[m])
+			t.print("", tb.nodes[m])
 		}
 		return
 	}
@@ -267,6 +269,292 @@ func TestTreeRouteMatch1(t *testing.T) {
 	})
 }
 
+func TestTreeRouteMatch2(t *testing.T) {
+	Convey("allowed methods", t, func() {
+		b2 := New()
+		b2.Get("/allow/:id", f)
+		b2.Post("/allow/:id", f)
+		b2.Delete("/allow/static", f)
+		r2 := b2.Router()
+
+		ru, _ := r2.Match("POST", "/allow/1", nil)
+		So(ru, ShouldNotBeNil)
+		ru, _ = r2.Match("PROPFIND", "/allow/1", nil)
+		So(ru, ShouldBeNil)
+		So(r2.AllowedMethods("/allow/1"), ShouldResemble, []string{"GET", "POST"})
+		So(r2.AllowedMethods("/allow/static"), ShouldResemble, []string{"GET", "POST", "DELETE"})
+		So(r2.AllowedMethods("/none"), ShouldBeEmpty)
+	})
+}
+
+func TestTreeRouteMatch3(t *testing.T) {
+	Convey("match route with constraints", t, func() {
+		b2 := New()
+		b2.Get("/user/:id<int>", func(c *Context) {
+			c.String(200, fmt.Sprintf("int:%d", c.ParamInt("id")))
+		})
+		b2.Get("/user/:name<alpha>", func(c *Context) {
+			c.String(200, "alpha:"+c.Param("name"))
+		})
+		b2.Get("/user/:any", func(c *Context) {
+			c.String(200, "any:"+c.Param("any"))
+		})
+		b2.Get("/user/:id<int>/posts", func(c *Context) {
+			c.String(200, fmt.Sprintf("posts:%d", c.ParamInt64("id")))
+		})
+		b2.Get("/user/:any/profile", func(c *Context) {
+			c.String(200, "profile:"+c.Param("any"))
+		})
+		b2.Get("/file/:name<[a-z]+\\.png>", func(c *Context) {
+			c.String(200, "png:"+c.Param("name"))
+		})
+		b2.Get("/price/:v<float>", func(c *Context) {
+			c.String(200, fmt.Sprintf("%.2f", c.ParamFloat("v")))
+		})
+		b2.Get("/page/:p<uint>?", func(c *Context) {
+			c.String(200, fmt.Sprintf("page:%d", c.ParamIntDefault("p", 1)))
+		}).Name("page")
+
+		cases := map[string]string{
+			"/user/123":        "int:123",
+			"/user/nice":       "alpha:nice",
+			"/user/nice-1":     "any:nice-1",
+			"/user/12/posts":   "posts:12",
+			"/user/12/profile": "profile:12",
+			"/file/logo.png":   "png:logo.png",
+			"/price/9.5":       "9.50",
+			"/page/3":          "page:3",
+			"/page":            "page:1",
+		}
+		for uri, body := range cases {
+			req, _ := http.NewRequest("GET", uri, nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, body)
+		}
+		for _, uri := range []string{"/file/logo.jpg", "/page/-1", "/price/abc", "/user/12/posts/1"} {
+			req, _ := http.NewRequest("GET", uri, nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		}
+		So(b2.URLFor("page", 2), ShouldEqual, "/page/2")
+
+		So(func() { b2.Get("/bad/:id<[a-z>", f) }, ShouldPanic)
+		So(func() { b2.Get("/bad/:id<int", f) }, ShouldPanic)
+		So(func() { b2.Get("/bad/:id?/list", f) }, ShouldPanic)
+		So(func() { b2.Get("/user/:uid<int>", f) }, ShouldPanic)
+	})
+}
+
+func TestTreeRouteMethod1(t *testing.T) {
+	Convey("custom http methods", t, func() {
+		b2 := New()
+		So(func() { b2.Route("/dav", "PROPFIND", f) }, ShouldPanic)
+		So(func() { b2.AddMethod("BAD METHOD") }, ShouldPanic)
+
+		b2.AddMethod("PROPFIND")
+		b2.AddMethod("MKCOL")
+		b2.AddMethod("GET")
+		b2.Route("/dav/*", "PROPFIND,MKCOL", func(c *Context) {
+			c.String(207, c.Req.Method)
+		})
+		b2.Get("/dav/*", f)
+
+		req, _ := http.NewRequest("PROPFIND", "/dav/a.txt", nil)
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, 207)
+		So(w.Body.String(), ShouldEqual, "PROPFIND")
+
+		req, _ = http.NewRequest("TRACE", "/dav/a.txt", nil)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(w.Header().Get("Allow"), ShouldEqual, "GET, PROPFIND, MKCOL")
+
+		So(b2.Router().Routes()["MKCOL"], ShouldResemble, []string{"/dav/*"})
+	})
+}
+
+func TestTreeMethodNotAllowed1(t *testing.T) {
+	Convey("method not allowed and auto options", t, func() {
+		b2 := New()
+		b2.Get("/users/:id", f)
+		b2.Put("/users/:id", f)
+		b2.Options("/opt", f)
+
+		Convey("method not allowed", func() {
+			req, _ := http.NewRequest("POST", "/users/1", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+			So(w.Header().Get("Allow"), ShouldEqual, "GET, PUT")
+
+			req, _ = http.NewRequest("POST", "/users", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("disable method not allowed", func() {
+			b2.SetMethodNotAllowed(nil)
+			req, _ := http.NewRequest("POST", "/users/1", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("auto options", func() {
+			req, _ := http.NewRequest("OPTIONS", "/users/1", nil)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+
+			b2.SetAutoOptions(true)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+			So(w.Header().Get("Allow"), ShouldEqual, "GET, PUT, OPTIONS")
+
+			req, _ = http.NewRequest("DELETE", "/users/1", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+			So(w.Header().Get("Allow"), ShouldEqual, "GET, PUT, OPTIONS")
+
+			req, _ = http.NewRequest("OPTIONS", "/opt", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			// registered OPTIONS is listed once
+			req, _ = http.NewRequest("DELETE", "/opt", nil)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+			So(w.Header().Get("Allow"), ShouldEqual, "OPTIONS")
+		})
+	})
+}
+
+func TestTreeRemove1(t *testing.T) {
+	Convey("remove route", t, func() {
+		b2 := New()
+		b2.SetAutoHead(true)
+		b2.Get("/users/:id", f).Name("user")
+		b2.Post("/users/:id", f)
+		b2.Get("/", f)
+
+		req, _ := http.NewRequest("GET", "/users/1", nil)
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		So(b2.Remove("GET", "/users/:id"), ShouldBeTrue)
+		So(b2.Remove("GET", "/users/:id"), ShouldBeFalse)
+		So(b2.Remove("GET", "/none"), ShouldBeFalse)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(w.Header().Get("Allow"), ShouldEqual, "POST")
+		So(b2.URLFor("user", 1), ShouldEqual, "")
+
+		req, _ = http.NewRequest("HEAD", "/", nil)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusOK)
+	})
+}
+
+func TestTreeSwapRoutes1(t *testing.T) {
+	Convey("swap route table", t, func() {
+		b2 := New()
+		b2.Get("/old", f)
+		b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/old", nil))
+
+		done := make(chan bool)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/old", nil))
+				}
+			}
+		}()
+		b2.SwapRoutes(func() {
+			b2.Get("/new", f).Name("new")
+			// not served before swapped
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, httptest.NewRequest("GET", "/new", nil))
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+		close(done)
+
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, httptest.NewRequest("GET", "/new", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		w = httptest.NewRecorder()
+		b2.ServeHTTP(w, httptest.NewRequest("HEAD", "/new", nil))
+		So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(b2.URLFor("new"), ShouldEqual, "/new")
+
+		Convey("panic in swap keeps the live routes", func() {
+			So(func() {
+				b2.SwapRoutes(func() {
+					b2.Get("/other", f)
+					panic("failed")
+				})
+			}, ShouldPanic)
+			w := httptest.NewRecorder()
+			b2.ServeHTTP(w, httptest.NewRequest("GET", "/new", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			b2.Get("/more", f)
+			w = httptest.NewRecorder()
+			b2.ServeHTTP(w, httptest.NewRequest("GET", "/more", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func TestTreeLiveNode1(t *testing.T) {
+	Convey("change live route node", t, func() {
+		b2 := New()
+		ru := b2.Get("/live", f)
+		b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/live", nil))
+
+		done := make(chan bool)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					b2.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/live", nil))
+				}
+			}
+		}()
+		for i := 0; i < 10; i++ {
+			ru.Name("live").Meta("i", i).Tag("t").Describe("live route").Timeout(time.Second)
+		}
+		ru.Use(func(c *Context) {
+			c.String(http.StatusAccepted, "used")
+		})
+		close(done)
+
+		w := httptest.NewRecorder()
+		b2.ServeHTTP(w, httptest.NewRequest("GET", "/live", nil))
+		So(w.Code, ShouldEqual, http.StatusAccepted)
+		So(ru.(*Node).GetMeta("i"), ShouldEqual, 9)
+		So(len(ru.(*Node).Tags()), ShouldEqual, 10)
+		So(b2.URLFor("live"), ShouldEqual, "/live")
+	})
+}
+
 func TestTreeRoutePrint1(t *testing.T) {
 	Convey("print route table", t, func() {
 		r.(*Tree).print("", nil)
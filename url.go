@@ -0,0 +1,159 @@
+package nice
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// P is named params of route for URLFor, the wide param is named *
+type P map[string]interface{}
+
+// Q is query params for URLFor, value could be a slice for multiple values
+type Q map[string]interface{}
+
+// ErrRouteNotFound is returned by URLForE when the route name is not registered
+var ErrRouteNotFound = errors.New("route name not found")
+
+// urlSegment is a part of route pattern for reverse routing
+type urlSegment struct {
+	static     string
+	param      string // param name, * for wide param
+	wide       bool
+	optional   bool
+	constraint *constraint
+}
+
+// parseURLSegments splits pattern into static and param segments
+func parseURLSegments(pattern string) []urlSegment {
+	var segs []urlSegment
+	var static []byte
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case ':':
+			if len(static) > 0 {
+				segs = append(segs, urlSegment{static: string(static)})
+				static = static[:0]
+			}
+			end := paramEnd(pattern, i+1)
+			seg := urlSegment{param: pattern[i+1 : end]}
+			if strings.HasSuffix(seg.param, "?") {
+				seg.param, seg.optional = seg.param[:len(seg.param)-1], true
+			}
+			if k := strings.IndexByte(seg.param, '<'); k >= 0 {
+				seg.param, seg.constraint = seg.param[:k], newConstraint(seg.param[k+1:len(seg.param)-1])
+			}
+			segs = append(segs, seg)
+			i = end - 1
+		case '*':
+			if len(static) > 0 {
+				segs = append(segs, urlSegment{static: string(static)})
+				static = static[:0]
+			}
+			segs = append(segs, urlSegment{param: "*", wide: true})
+			i = len(pattern)
+		default:
+			static = append(static, pattern[i])
+		}
+	}
+	if len(static) > 0 {
+		segs = append(segs, urlSegment{static: string(static)})
+	}
+	return segs
+}
+
+// buildURL fills params of segments by args, args could be P, Q, url.Values
+// or positional values of params in order, extra positional values are appended.
+func buildURL(name string, segs []urlSegment, args []interface{}) (string, error) {
+	named := make(P)
+	query := make(url.Values)
+	var values []interface{}
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case P:
+			for k := range v {
+				named[k] = v[k]
+			}
+		case Q:
+			for k := range v {
+				addQuery(query, k, v[k])
+			}
+		case url.Values:
+			for k := range v {
+				query[k] = append(query[k], v[k]...)
+			}
+		default:
+			values = append(values, arg)
+		}
+	}
+
+	var b strings.Builder
+	for _, seg := range segs {
+		if seg.param == "" {
+			b.WriteString(seg.static)
+			continue
+		}
+		v, ok := named[seg.param]
+		if !ok && len(values) > 0 {
+			v, ok, values = values[0], true, values[1:]
+		}
+		if !ok || v == nil {
+			if seg.optional {
+				// remove the slash before optional param
+				s := strings.TrimSuffix(b.String(), "/")
+				b.Reset()
+				b.WriteString(s)
+				continue
+			}
+			return "", fmt.Errorf("nice: URLFor %s missing param %s", name, seg.param)
+		}
+		s := fmt.Sprint(v)
+		if seg.constraint != nil {
+			if _, ok := seg.constraint.match(s); !ok {
+				return "", fmt.Errorf("nice: URLFor %s param %s=%q not match <%s>", name, seg.param, s, seg.constraint.expr)
+			}
+		}
+		if seg.wide {
+			// keep slash in wide param
+			parts := strings.Split(s, "/")
+			for i := range parts {
+				parts[i] = url.PathEscape(parts[i])
+			}
+			b.WriteString(strings.Join(parts, "/"))
+		} else {
+			b.WriteString(url.PathEscape(s))
+		}
+	}
+	// extra values are appended as is, like previous versions
+	for _, v := range values {
+		b.WriteString(fmt.Sprint(v))
+	}
+
+	s := b.String()
+	if s == "" {
+		s = "/"
+	}
+	if len(query) > 0 {
+		s += "?" + query.Encode()
+	}
+	return s, nil
+}
+
+// addQuery adds query value, slice is added as multiple values
+func addQuery(query url.Values, key string, v interface{}) {
+	switch v := v.(type) {
+	case []string:
+		query[key] = append(query[key], v...)
+	case []interface{}:
+		for i := range v {
+			query.Add(key, fmt.Sprint(v[i]))
+		}
+	case []int:
+		for i := range v {
+			query.Add(key, fmt.Sprint(v[i]))
+		}
+	default:
+		query.Add(key, fmt.Sprint(v))
+	}
+}
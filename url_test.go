@@ -0,0 +1,59 @@
+package nice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestURLFor1(t *testing.T) {
+	Convey("reverse routing", t, func() {
+		b2 := New()
+		b2.Get("/users/:id<int>/posts/:slug", f).Name("post")
+		b2.Get("/static/*", f).Name("static")
+		b2.Get("/page/:p<uint>?", f).Name("page")
+		b2.Get("/", f).Name("home")
+
+		Convey("positional and named params", func() {
+			So(b2.URLFor("post", 5, "hello"), ShouldEqual, "/users/5/posts/hello")
+			So(b2.URLFor("post", P{"id": 5, "slug": "hello"}), ShouldEqual, "/users/5/posts/hello")
+			So(b2.URLFor("post", P{"slug": "hello"}, 5), ShouldEqual, "/users/5/posts/hello")
+			So(b2.URLFor("home"), ShouldEqual, "/")
+			So(b2.URLFor("post", 1, "x", "/edit", 2), ShouldEqual, "/users/1/posts/x/edit2")
+			So(b2.URLFor("post", 1, "x", "/edit", Q{"a": 1}), ShouldEqual, "/users/1/posts/x/edit?a=1")
+		})
+		Convey("escape and wildcard", func() {
+			So(b2.URLFor("post", 5, "a b/c?"), ShouldEqual, "/users/5/posts/a%20b%2Fc%3F")
+			So(b2.URLFor("static", "css/a b.css"), ShouldEqual, "/static/css/a%20b.css")
+			So(b2.URLFor("static", P{"*": "js/app.js"}), ShouldEqual, "/static/js/app.js")
+		})
+		Convey("query and optional", func() {
+			So(b2.URLFor("post", P{"id": 5, "slug": "x"}, Q{"tab": "x", "tag": []string{"a", "b"}}), ShouldEqual, "/users/5/posts/x?tab=x&tag=a&tag=b")
+			So(b2.URLFor("home", url.Values{"q": {"nice"}}), ShouldEqual, "/?q=nice")
+			So(b2.URLFor("page", 2), ShouldEqual, "/page/2")
+			So(b2.URLFor("page"), ShouldEqual, "/page")
+		})
+		Convey("errors", func() {
+			_, err := b2.URLForE("none")
+			So(err, ShouldEqual, ErrRouteNotFound)
+			_, err = b2.URLForE("post", 5)
+			So(err.Error(), ShouldContainSubstring, "missing param slug")
+			_, err = b2.URLForE("post", "abc", "x")
+			So(err.Error(), ShouldContainSubstring, "not match <int>")
+			So(b2.URLFor("post", 5), ShouldEqual, "")
+		})
+		Convey("absolute url", func() {
+			var abs string
+			b2.Get("/abs", func(c *Context) {
+				abs = c.AbsURLFor("post", 1, "x", Q{"a": 1})
+			})
+			req, _ := http.NewRequest("GET", "/abs", nil)
+			req.Host = "example.com:8080"
+			b2.ServeHTTP(httptest.NewRecorder(), req)
+			So(abs, ShouldEqual, "http://example.com:8080/users/1/posts/x?a=1")
+		})
+	})
+}